@@ -0,0 +1,153 @@
+package raycaster
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// AnimatedTexture represents a wall or floor texture cycling through frames over time, with optional scrolling,
+// such as flowing water, flickering screens, or pulsing lava
+type AnimatedTexture struct {
+	// Frames images of a wall texture animation, such as sub-images of a sprite sheet
+	Frames []*ebiten.Image
+
+	// FloorFrames images of a floor or ceiling texture animation
+	FloorFrames []*image.RGBA
+
+	// Durations of each frame (seconds), the last duration is also used for any frames after it
+	Durations []float64
+
+	// ScrollX, ScrollY distance the texture scrolls each second, as a fraction of its width and height
+	// (walls only scroll horizontally)
+	ScrollX, ScrollY float64
+}
+
+// NewAnimatedTexture creates a wall texture animation of frames with the same duration (seconds)
+func NewAnimatedTexture(frames []*ebiten.Image, frameDuration float64) *AnimatedTexture {
+	return &AnimatedTexture{Frames: frames, Durations: []float64{frameDuration}}
+}
+
+// NewAnimatedTextureSheet creates a wall texture animation of frames with the same duration (seconds)
+// from a sprite sheet region, with the first frame at frameRect and each next frame to the right of it
+func NewAnimatedTextureSheet(sheet *ebiten.Image, frameRect image.Rectangle, numFrames int, frameDuration float64) *AnimatedTexture {
+	frames := make([]*ebiten.Image, numFrames)
+	for i := 0; i < numFrames; i++ {
+		frames[i] = sheet.SubImage(frameRect.Add(image.Pt(i*frameRect.Dx(), 0))).(*ebiten.Image)
+	}
+	return NewAnimatedTexture(frames, frameDuration)
+}
+
+// NewAnimatedFloorTexture creates a floor or ceiling texture animation of frames with the same duration (seconds)
+func NewAnimatedFloorTexture(frames []*image.RGBA, frameDuration float64) *AnimatedTexture {
+	return &AnimatedTexture{FloorFrames: frames, Durations: []float64{frameDuration}}
+}
+
+// SetAnimatedTexture animates a wall texture, wherever the TextureHandler returns the texture image
+// the current frame of the animation is rendered instead (nil to stop animating it)
+func (c *Camera) SetAnimatedTexture(texture *ebiten.Image, animation *AnimatedTexture) {
+	if animation == nil {
+		delete(c.animatedTextures, texture)
+		return
+	}
+
+	if c.animatedTextures == nil {
+		c.animatedTextures = make(map[*ebiten.Image]*AnimatedTexture)
+	}
+	c.animatedTextures[texture] = animation
+}
+
+// SetAnimatedFloorTexture animates a floor or ceiling texture, wherever the TextureHandler returns the texture image
+// the current frame of the animation is rendered instead (nil to stop animating it)
+func (c *Camera) SetAnimatedFloorTexture(texture *image.RGBA, animation *AnimatedTexture) {
+	if animation == nil {
+		delete(c.animatedFloorTextures, texture)
+		return
+	}
+
+	if c.animatedFloorTextures == nil {
+		c.animatedFloorTextures = make(map[*image.RGBA]*AnimatedTexture)
+	}
+	c.animatedFloorTextures[texture] = animation
+}
+
+// UpdateAt updates the camera view the same as Update, with texture animations at the given time (seconds)
+func (c *Camera) UpdateAt(sprites []Sprite, animationTime float64) {
+	c.animationTime = animationTime
+	c.Update(sprites)
+}
+
+// AnimationTime returns the time (seconds) that texture animations were last updated at
+func (c *Camera) AnimationTime() float64 {
+	return c.animationTime
+}
+
+// frameAt returns the index of the frame shown at the given time (seconds) out of the number of frames
+func (a *AnimatedTexture) frameAt(t float64, numFrames int) int {
+	if numFrames == 0 || len(a.Durations) == 0 {
+		return 0
+	}
+
+	duration := func(i int) float64 {
+		if i < len(a.Durations) {
+			return a.Durations[i]
+		}
+		return a.Durations[len(a.Durations)-1]
+	}
+
+	var total float64
+	for i := 0; i < numFrames; i++ {
+		total += duration(i)
+	}
+	if total <= 0 {
+		return 0
+	}
+
+	t = math.Mod(t, total)
+	if t < 0 {
+		t += total
+	}
+	for i := 0; i < numFrames; i++ {
+		if t < duration(i) {
+			return i
+		}
+		t -= duration(i)
+	}
+	return numFrames - 1
+}
+
+// scrollAt returns the scrolling offset at the given time (seconds) as a fraction of the texture width and height
+func (a *AnimatedTexture) scrollAt(t float64) (float64, float64) {
+	scrollX := a.ScrollX * t
+	scrollY := a.ScrollY * t
+	return scrollX - math.Floor(scrollX), scrollY - math.Floor(scrollY)
+}
+
+// animatedTextureAt returns the current frame and horizontal scrolling of the wall texture if it is animated
+func (c *Camera) animatedTextureAt(texture *ebiten.Image) (*ebiten.Image, float64) {
+	animation, ok := c.animatedTextures[texture]
+	if !ok {
+		return texture, 0
+	}
+
+	if len(animation.Frames) > 0 {
+		texture = animation.Frames[animation.frameAt(c.animationTime, len(animation.Frames))]
+	}
+	scrollX, _ := animation.scrollAt(c.animationTime)
+	return texture, scrollX
+}
+
+// animatedFloorTextureAt returns the current frame and scrolling of the floor or ceiling texture if it is animated
+func (c *Camera) animatedFloorTextureAt(texture *image.RGBA) (*image.RGBA, float64, float64) {
+	animation, ok := c.animatedFloorTextures[texture]
+	if !ok {
+		return texture, 0, 0
+	}
+
+	if len(animation.FloorFrames) > 0 {
+		texture = animation.FloorFrames[animation.frameAt(c.animationTime, len(animation.FloorFrames))]
+	}
+	scrollX, scrollY := animation.scrollAt(c.animationTime)
+	return texture, scrollX, scrollY
+}
@@ -55,16 +55,28 @@ type Camera struct {
 	floor *ebiten.Image
 	sky   *ebiten.Image
 
-	//--texture width--//
+	// distance fog blending toward the fog color
+	fogMode          FogMode
+	fogColor         color.NRGBA
+	fogStart, fogEnd float64
+
+	// sky rendering mode and layers drawn over a panoramic sky
+	skyMode   SkyMode
+	skyLayers []*SkyLayer
+
+	//--texture width and height of the floorbox and skybox textures--//
 	texSize int
 
-	//--structs that contain rects and tints for each level render--//
-	levels   []*level
+	//--structs that contain rects and tints for each level render, with a layer for walls seen past closer walls--//
+	levels   [][]*level
 	floorLvl *horLevel
-	slices   []*image.Rectangle
 
-	// zbuffer for sprite casting
-	zBuffer []float64
+	// wall slices of a column in draw order
+	wallOrder []*level
+
+	// top faces of walls lower than their level for each level and column
+	topFaces [][][]topFace
+
 	// sprites
 	sprites    []Sprite
 	spriteLvls []*level
@@ -95,6 +107,32 @@ type Camera struct {
 	// advanced option to always provide sprite screen rect bounds even when sprite is not being rendered
 	alwaysSetSpriteScreenRect bool
 
+	// doors registered by map cell
+	doors map[mapCell]*Door
+
+	// wall blocks moving through the map grid
+	pushWalls []*PushWall
+
+	// point lights illuminating everything around them
+	lights []*Light
+
+	// baked illumination of static lights
+	lightmap *BakedLightmap
+
+	// spotlight cone carried by the camera
+	flashlight *Flashlight
+
+	// flat sprites lying on floors
+	floorSprites []*FloorSprite
+
+	// decals registered on wall faces
+	decals map[cellFace][]*Decal
+
+	// wall and floor textures replaced by the current frame of their animation
+	animatedTextures      map[*ebiten.Image]*AnimatedTexture
+	animatedFloorTextures map[*image.RGBA]*AnimatedTexture
+	animationTime         float64
+
 	// used for concurrency
 	semaphore chan struct{}
 }
@@ -112,7 +150,7 @@ func NewCamera(width int, height int, texSize int, mapObj Map, tex TextureHandle
 
 	//--camera position, init to some start position--//
 	c.pos = &geom.Vector2{X: 1.0, Y: 1.0}
-	c.camZ = 0.0
+	c.SetPositionZ(0.5)
 	c.SetHeadingAngle(0)
 	c.SetPitchAngle(0)
 
@@ -154,11 +192,11 @@ func (c *Camera) SetViewSize(width, height int) {
 
 	// creating level slices based on screen size
 	c.levels = c.createLevels(c.mapObj.NumLevels())
-	c.slices = makeSlices(c.texSize, c.texSize, 0, 0)
+	c.topFaces = make([][][]topFace, c.mapObj.NumLevels())
+	for i := range c.topFaces {
+		c.topFaces[i] = make([][]topFace, c.w)
+	}
 	c.floorLvl = c.createFloorLevel()
-
-	// set zbuffer based on screen width
-	c.zBuffer = make([]float64, width)
 }
 
 func (c *Camera) ViewSize() (int, int) {
@@ -277,6 +315,16 @@ func (c *Camera) raycast() {
 
 	wg.Wait()
 
+	//FLOOR AND CEILING CASTING
+	//after all levels since walls of any level can be in front of a floor
+	c.updateFloorSprites()
+	for x := 0; x < c.w; x++ {
+		wg.Add(1)
+		go c.asyncCastFloor(x, &wg)
+	}
+
+	wg.Wait()
+
 	//SPRITE CASTING
 	numSprites := len(c.sprites)
 	c.spriteOrder = make([]int, numSprites)
@@ -304,10 +352,21 @@ func (c *Camera) asyncCastLevel(levelNum int, wg *sync.WaitGroup) {
 	rMap := c.mapObj.Level(levelNum)
 
 	for x := 0; x < c.w; x++ {
-		c.castLevel(x, rMap, c.levels[levelNum], levelNum, wg)
+		c.castLevel(x, rMap, levelNum)
 	}
 }
 
+func (c *Camera) asyncCastFloor(x int, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	c.semaphore <- struct{}{} // Lock
+	defer func() {
+		<-c.semaphore // Unlock
+	}()
+
+	c.castFloor(x)
+}
+
 func (c *Camera) asyncCastSprite(spriteNum int, wg *sync.WaitGroup) {
 	defer wg.Done()
 
@@ -321,14 +380,7 @@ func (c *Camera) asyncCastSprite(spriteNum int, wg *sync.WaitGroup) {
 
 // credit : Raycast loop and setting up of vectors for matrix calculations
 // courtesy - http://lodev.org/cgtutor/raycasting.html
-func (c *Camera) castLevel(x int, grid [][]int, lvl *level, levelNum int, wg *sync.WaitGroup) {
-	var _cts, _sv []*image.Rectangle
-	var _st []*color.RGBA
-
-	_cts = lvl.Cts
-	_sv = lvl.Sv
-	_st = lvl.St
-
+func (c *Camera) castLevel(x int, grid [][]int, levelNum int) {
 	//calculate ray position and direction
 	cameraX := 2.0*float64(x)/float64(c.w) - 1.0 //x-coordinate in camera space
 	rayDirX := c.dir.X + c.plane.X*cameraX
@@ -375,6 +427,21 @@ func (c *Camera) castLevel(x int, grid [][]int, lvl *level, levelNum int, wg *sy
 		sideDistY = (float64(mapY) + 1.0 - rayPosY) * deltaDistY
 	}
 
+	// top faces of walls lower than their level are found again for each column
+	c.topFaces[levelNum][x] = c.topFaces[levelNum][x][:0]
+
+	//faces between adjacent wall blocks are hidden when seeing past walls, including below a rooftop the camera is on
+	var prevBlock bool
+	var prevBottom, prevTop float64
+	if c.inBounds(mapX, mapY) && c.isWallBlock(grid, mapX, mapY, levelNum) {
+		prevBottom, prevTop = c.cellHeight(levelNum, mapX, mapY)
+		prevBlock = c.canSeePast(levelNum, prevBottom, prevTop)
+		c.addTopFace(x, levelNum, mapX, mapY, prevTop, 0, math.Min(sideDistX, sideDistY))
+	}
+
+	//each wall hit seen past a closer wall is rendered on its own layer
+	layer := 0
+
 	//perform DDA
 	for hit == 0 {
 		//jump to next map square, OR in x-direction, OR in y-direction
@@ -396,20 +463,132 @@ func (c *Camera) castLevel(x int, grid [][]int, lvl *level, levelNum int, wg *sy
 		}
 
 		//Check if ray has hit a wall
-		if mapX >= 0 && mapY >= 0 && mapX < c.mapWidth && mapY < c.mapHeight {
-			if perpWallDist > c.renderDistance {
-				// hit render distance bounds
-				hit = 2
-			} else if perpWallDist <= c.renderDistance && grid[mapX][mapY] > 0 {
-				// only render walls within render distance
+		if !c.inBounds(mapX, mapY) || perpWallDist > c.renderDistance {
+			//hit grid boundary or render distance bounds
+			hit = 2
+		} else if wallHit, ok := c.castCell(grid, levelNum, mapX, mapY, side, perpWallDist, rayPosX, rayPosY, rayDirX, rayDirY); ok {
+			if !(prevBlock && wallHit.block && prevBottom <= wallHit.bottom && wallHit.top <= prevTop) {
+				c.castWallSlice(x, levelNum, layer, wallHit, rayDirX, rayDirY)
+				layer++
+			}
+
+			if wallHit.block {
+				c.addTopFace(x, levelNum, mapX, mapY, wallHit.top, perpWallDist, math.Min(sideDistX, sideDistY))
+			}
+
+			//the camera can see past walls it is above or below, such as looking out from a rooftop or over a low wall,
+			//and through thin walls and doors, such as fences, bars or windows
+			seePast := c.canSeePast(levelNum, wallHit.bottom, wallHit.top)
+			prevBlock = seePast && wallHit.block
+			prevBottom, prevTop = wallHit.bottom, wallHit.top
+			if !seePast && !wallHit.thin {
 				hit = 1
 			}
 		} else {
-			//hit grid boundary
-			hit = 2
+			prevBlock = false
+		}
+	}
+
+	if hit == 2 && layer == 0 {
+		// no walls were hit, the bounds are still used for the convergence point
+		c.castWallSlice(x, levelNum, layer, &wallHit{side: side, perpWallDist: perpWallDist, bottom: 0, top: 1, boundary: true}, rayDirX, rayDirY)
+		layer++
+	}
+
+	// clear layers not used by this column
+	for ; layer < len(c.levels[levelNum]); layer++ {
+		c.levels[levelNum][layer].CurrTex[x] = nil
+	}
+}
+
+// wallHit --represents where a ray hit a wall within a map cell--//
+type wallHit struct {
+	mapX, mapY   int
+	side         int
+	perpWallDist float64
+
+	//where exactly the wall was hit
+	wallX float64
+
+	//bottom and top of the wall relative to the bottom of its level
+	bottom, top float64
+
+	//regular wall block filling the whole map cell
+	block bool
+
+	//push wall providing the texture from the map cell it started from
+	pushWall *PushWall
+
+	//grid boundary or render distance bounds, which are not textured
+	boundary bool
+
+	//thin wall or door that the ray continues past, seeing through its transparent pixels
+	thin bool
+}
+
+// castCell checks if the ray hits a wall within the given map cell as it enters at the given perpendicular distance
+func (c *Camera) castCell(grid [][]int, levelNum, mapX, mapY, side int, perpWallDist, rayPosX, rayPosY, rayDirX, rayDirY float64) (*wallHit, bool) {
+	if grid[mapX][mapY] > 0 && !c.isPushWallOrigin(mapX, mapY, levelNum) {
+		bottom, top := c.cellHeight(levelNum, mapX, mapY)
+
+		if door := c.Door(mapX, mapY, levelNum); door != nil {
+			doorDist, doorX, doorSide, doorHit := door.cast(rayPosX, rayPosY, rayDirX, rayDirY, mapX, mapY)
+			if !doorHit || doorDist > c.renderDistance {
+				// ray passes through the open part of the door
+				return nil, false
+			}
+			return &wallHit{mapX: mapX, mapY: mapY, side: doorSide, perpWallDist: doorDist, wallX: doorX, bottom: bottom, top: top, thin: true}, true
+		}
+
+		if thinWalls, ok := c.mapObj.(ThinWallMap); ok {
+			orientation, offset := thinWalls.ThinWallAt(mapX, mapY, levelNum)
+			if orientation != ThinWallNone {
+				thinWallDist, thinWallX, thinWallSide, thinWallHit := castThinWall(rayPosX, rayPosY, rayDirX, rayDirY, mapX, mapY, orientation, offset)
+				if !thinWallHit || thinWallDist > c.renderDistance {
+					// ray passes by the thin wall within this map cell
+					return nil, false
+				}
+				return &wallHit{mapX: mapX, mapY: mapY, side: thinWallSide, perpWallDist: thinWallDist, wallX: thinWallX, bottom: bottom, top: top, thin: true}, true
+			}
+		}
+
+		//calculate value of wallX
+		var wallX float64
+		if side == 0 {
+			wallX = rayPosY + perpWallDist*rayDirY
+		} else {
+			wallX = rayPosX + perpWallDist*rayDirX
 		}
+		wallX -= math.Floor(wallX)
+
+		return &wallHit{mapX: mapX, mapY: mapY, side: side, perpWallDist: perpWallDist, wallX: wallX, bottom: bottom, top: top, block: true}, true
+	}
+
+	if pushWall, pushWallDist, pushWallX, pushWallSide := c.castPushWalls(rayPosX, rayPosY, rayDirX, rayDirY, mapX, mapY, levelNum); pushWall != nil && pushWallDist <= c.renderDistance {
+		// push wall moving through this map cell, keeping the height of the map cell it started from
+		bottom, top := c.cellHeight(levelNum, pushWall.X, pushWall.Y)
+		return &wallHit{mapX: mapX, mapY: mapY, side: pushWallSide, perpWallDist: pushWallDist, wallX: pushWallX, bottom: bottom, top: top, pushWall: pushWall}, true
 	}
 
+	return nil, false
+}
+
+// castWallSlice sets the vertical slice of a wall hit for the column on the given level layer
+func (c *Camera) castWallSlice(x, levelNum, layer int, hit *wallHit, rayDirX, rayDirY float64) {
+	lvl := c.levelLayer(levelNum, layer)
+
+	var _cts, _sv []*image.Rectangle
+	var _st []*color.RGBA
+	var _zb []float64
+
+	_cts = lvl.Cts
+	_sv = lvl.Sv
+	_st = lvl.St
+	_zb = lvl.Zb
+
+	side := hit.side
+	perpWallDist := hit.perpWallDist
+
 	//Calculate height of line to draw on screen
 	lineHeight := int(float64(c.h) / perpWallDist)
 
@@ -417,40 +596,72 @@ func (c *Camera) castLevel(x int, grid [][]int, lvl *level, levelNum int, wg *sy
 	drawStart := (-lineHeight/2 + c.h/2) + c.pitch + int(c.camZ/perpWallDist) - lineHeight*levelNum
 	drawEnd := drawStart + lineHeight
 
+	if hit.bottom != 0 || hit.top != 1 {
+		// walls not filling the whole level are projected from their bottom and top within it
+		levelBottom := drawEnd
+		drawStart = levelBottom - int(float64(lineHeight)*hit.top)
+		drawEnd = levelBottom - int(float64(lineHeight)*hit.bottom)
+	}
+
 	//--due to modern way of drawing using quads this is removed to avoid glitches at the edges--//
 	// if drawStart < 0 { drawStart = 0 }
 	// if drawEnd >= c.h { drawEnd = c.h - 1 }
 
-	//calculate value of wallX
-	var wallX float64 //where exactly the wall/boundary was hit
-	if side == 0 {
-		wallX = rayPosY + perpWallDist*rayDirY
-	} else {
-		wallX = rayPosX + perpWallDist*rayDirX
-	}
-	wallX -= math.Floor(wallX)
-
 	//texturing calculations
 	var texture *ebiten.Image
-	if hit == 1 && mapX >= 0 && mapY >= 0 && mapX < c.mapWidth && mapY < c.mapHeight {
-		texture = c.tex.TextureAt(mapX, mapY, levelNum, side)
+	face := hitFace(side, rayDirX, rayDirY)
+	if hit.pushWall != nil {
+		// push walls keep the texture of the map cell they started from
+		texture = c.wallTextureAt(hit.pushWall.X, hit.pushWall.Y, levelNum, side, face)
+	} else if !hit.boundary {
+		texture = c.wallTextureAt(hit.mapX, hit.mapY, levelNum, side, face)
+	}
+
+	//--current frame of animated textures--//
+	var texScroll float64
+	if texture != nil {
+		texture, texScroll = c.animatedTextureAt(texture)
 	}
 
-	c.levels[levelNum].CurrTex[x] = texture
+	lvl.CurrTex[x] = texture
+	lvl.Tw[x] = hit.thin
+	lvl.Cf[x] = cellFace{cell: mapCell{x: hit.mapX, y: hit.mapY, levelNum: levelNum}, face: face}
 
 	if texture != nil {
+		//--a map cell spans the shorter side of the texture, wide textures tile across cells and tall ones across levels--//
+		texBounds := texture.Bounds()
+		texWidth, texHeight := texBounds.Dx(), texBounds.Dy()
+		texUnit := geom.MinInt(texWidth, texHeight)
+
+		//map cell coordinate along the wall, push walls keep the one of the map cell they started from
+		cellX, cellY := hit.mapX, hit.mapY
+		if hit.pushWall != nil {
+			cellX, cellY = hit.pushWall.X, hit.pushWall.Y
+		}
+		wallPos := float64(cellY)
+		if side == 1 {
+			wallPos = float64(cellX)
+		}
+
 		//x coordinate on the texture
-		texX := int(wallX * float64(c.texSize))
+		texX := int((wallPos+hit.wallX)*float64(texUnit)) % texWidth
 		if side == 0 && rayDirX > 0 {
-			texX = c.texSize - texX - 1
+			texX = texWidth - texX - 1
 		}
 
 		if side == 1 && rayDirY < 0 {
-			texX = c.texSize - texX - 1
+			texX = texWidth - texX - 1
 		}
 
+		//scrolling of animated textures
+		texX = (texX + int(texScroll*float64(texWidth))) % texWidth
+
+		//y coordinate on the texture of the bottom of the level, tall textures start from the bottom of the texture
+		texY := texHeight - (levelNum%(texHeight/texUnit)+1)*texUnit
+
 		//--set current texture slice to be slice x--//
-		_cts[x] = c.slices[texX]
+		sliceRect := image.Rect(texBounds.Min.X+texX, texBounds.Min.Y+texY, texBounds.Min.X+texX+1, texBounds.Min.Y+texY+texUnit)
+		_cts[x] = &sliceRect
 
 		//--set height of slice--//
 		_sv[x].Min.Y = drawStart
@@ -458,13 +669,25 @@ func (c *Camera) castLevel(x int, grid [][]int, lvl *level, levelNum int, wg *sy
 		//--set draw start of slice--//
 		_sv[x].Max.Y = drawEnd
 
+		//--decals on the wall face, positioned as viewed from outside--//
+		faceU := hit.wallX
+		if (side == 0 && rayDirX > 0) || (side == 1 && rayDirY < 0) {
+			faceU = 1 - hit.wallX
+		}
+		c.castDecals(lvl, x, cellX, cellY, levelNum, face, faceU)
+
 		//// LIGHTING ////
 		//--distance based dimming of light--//
 		shadowDepth := math.Sqrt(perpWallDist) * c.lightFalloff
-		_st[x] = &color.RGBA{255, 255, 255, 255}
-		_st[x].R = byte(geom.ClampInt(int(float64(_st[x].R)+shadowDepth+c.globalIllumination), int(c.minLightRGB.R), int(c.maxLightRGB.R)))
-		_st[x].G = byte(geom.ClampInt(int(float64(_st[x].G)+shadowDepth+c.globalIllumination), int(c.minLightRGB.G), int(c.maxLightRGB.G)))
-		_st[x].B = byte(geom.ClampInt(int(float64(_st[x].B)+shadowDepth+c.globalIllumination), int(c.minLightRGB.B), int(c.maxLightRGB.B)))
+		//--point lights at the middle of the wall slice--//
+		hitX, hitY := c.pos.X+perpWallDist*rayDirX, c.pos.Y+perpWallDist*rayDirY
+		lights := c.illumination(hitX, hitY, float64(levelNum)+(hit.bottom+hit.top)/2)
+		lights.add(c.flashlightIllumination(hitX, hitY, float64(levelNum)+hit.bottom, float64(levelNum)+hit.top))
+		if c.lightmap != nil {
+			// push walls use the light baked for the map cell they started from, cells they move through have none
+			lights.add(c.lightmap.wallAt(levelNum, cellX, cellY, face, hit.wallX))
+		}
+		_st[x] = c.lightTint(shadowDepth+c.cellIllumination(hit.mapX, hit.mapY, levelNum), lights)
 
 		//--add a bit of tint to differentiate between walls of a corner--//
 		if side == 0 {
@@ -473,6 +696,9 @@ func (c *Camera) castLevel(x int, grid [][]int, lvl *level, levelNum int, wg *sy
 			_st[x].G = byte(geom.ClampInt(int(_st[x].G)-wallDiff, 0, 255))
 			_st[x].B = byte(geom.ClampInt(int(_st[x].B)-wallDiff, 0, 255))
 		}
+
+		//--blend toward the fog color with distance--//
+		lvl.Sa[x] = c.applyFog(_st[x], perpWallDist)
 	}
 
 	// determine if is convergence point that hit a wall
@@ -490,115 +716,339 @@ func (c *Camera) castLevel(x int, grid [][]int, lvl *level, levelNum int, wg *sy
 	}
 
 	//SET THE ZBUFFER FOR THE SPRITE CASTING
-	if levelNum == 0 {
-		// for now only rendering sprites on first level
-		c.zBuffer[x] = perpWallDist //perpendicular distance is used
+	_zb[x] = perpWallDist //perpendicular distance is used
+}
+
+// wallTextureAt returns the wall texture at the map coordinates and level number as viewed from the face
+func (c *Camera) wallTextureAt(mapX, mapY, levelNum, side int, face WallFace) *ebiten.Image {
+	if faceHandler, ok := c.tex.(FaceTextureHandler); ok {
+		return faceHandler.TextureAtFace(mapX, mapY, levelNum, face)
 	}
+	return c.tex.TextureAt(mapX, mapY, levelNum, side)
+}
 
-	//// FLOOR CASTING ////
-	if levelNum == 0 {
-		// for now only rendering floor on first level
-		if drawEnd < 0 {
-			drawEnd = c.h //becomes < 0 when the integer overflows
-		}
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			var floorXWall, floorYWall float64
-
-			//4 different wall directions possible
-			if side == 0 && rayDirX > 0 {
-				floorXWall = float64(mapX)
-				floorYWall = float64(mapY) + wallX
-			} else if side == 0 && rayDirX < 0 {
-				floorXWall = float64(mapX) + 1.0
-				floorYWall = float64(mapY) + wallX
-			} else if side == 1 && rayDirY > 0 {
-				floorXWall = float64(mapX) + wallX
-				floorYWall = float64(mapY)
-			} else {
-				floorXWall = float64(mapX) + wallX
-				floorYWall = float64(mapY) + 1.0
-			}
+// castFloor renders the floor of each level and the ceiling for the column, nearest surface first
+func (c *Camera) castFloor(x int) {
+	ceilingHandler, hasCeiling := c.tex.(CeilingTextureHandler)
 
-			var distWall, distPlayer, currentDist float64
+	// floors of upper levels are the top faces of the level below, the highest one is the top of the map
+	floorLevel := int(math.Ceil(c.posZ)) - 1
+	if topFloor := c.mapObj.NumLevels(); floorLevel > topFloor {
+		floorLevel = topFloor
+	}
 
-			distWall = perpWallDist
-			distPlayer = 0.0
+	for y := 0; y < c.h; y++ {
+		// rows below the horizon see floors below the camera, rows above it see the ceiling above the camera
+		rowDiv := 2.0*float64(y-c.pitch) - float64(c.h)
 
-			//draw the floor from drawEnd to the bottom of the screen
-			for y := drawEnd; y < c.h; y++ {
-				currentDist = (float64(c.h) + (2.0 * c.camZ)) / (2.0*float64(y-c.pitch) - float64(c.h))
-				if currentDist > c.renderDistance {
-					continue
+		if rowDiv > 0 {
+			//nearest top face of a wall lower than its level seen at the pixel, if any
+			face, faceDist := c.topFaceAt(x, rowDiv)
+
+			//draw the floor from the highest level below the camera down to the ground floor
+			drawn := false
+			for levelNum := floorLevel; levelNum >= 0 && !drawn; levelNum-- {
+				if face != nil && c.surfaceDist(float64(levelNum), rowDiv) >= faceDist {
+					break
 				}
+				drawn = c.castSurfacePixel(x, y, float64(levelNum), rowDiv, func(mapX, mapY int) *image.RGBA {
+					if c.hasTopFace(mapX, mapY, levelNum-1) {
+						// the wall below does not reach this floor, its own top face is drawn instead
+						return nil
+					}
+					return c.floorTextureAt(mapX, mapY, levelNum)
+				})
+			}
 
-				weight := (currentDist - distPlayer) / (distWall - distPlayer)
+			if !drawn && face != nil {
+				c.castSurfacePixel(x, y, face.z, rowDiv, func(int, int) *image.RGBA {
+					return c.floorTextureAt(face.mapX, face.mapY, face.levelNum+1)
+				})
+			}
+		} else if rowDiv < 0 && hasCeiling && c.posZ < 1 {
+			// for now only rendering ceiling on first level
+			c.castSurfacePixel(x, y, 1, rowDiv, ceilingHandler.CeilingTextureAt)
+		}
+	}
 
-				currentFloorX := weight*floorXWall + (1.0-weight)*rayPosX
-				currentFloorY := weight*floorYWall + (1.0-weight)*rayPosY
+	c.clipThinWalls(x)
+}
 
-				// do not call FloorTextureAt interface if X/Y is outside of map bounds
-				if currentFloorX < 0 || currentFloorY < 0 || int(currentFloorX) >= c.mapWidth || int(currentFloorY) >= c.mapHeight {
-					continue
-				}
+// clipThinWalls clips the slices of thin walls and doors in the column against the walls and floors in front of them,
+// since they are drawn after the floor along with the sprites
+func (c *Camera) clipThinWalls(x int) {
+	for _, layers := range c.levels {
+		for _, lvl := range layers {
+			if lvl.CurrTex[x] == nil || !lvl.Tw[x] {
+				continue
+			}
 
-				if x == convergenceCol && y == convergenceRow {
-					// use pitch angle and perpendicular distance (adjusted for fov zoom) to find Z point of convergence
-					convergencePerpDist := currentDist * c.fovDepth
-					convergenceLine3d := geom3d.Line3dFromBaseAngle(c.pos.X, c.pos.Y, c.posZ, c.headingAngle, c.pitchAngle, convergencePerpDist)
-					convergenceDistance := convergenceLine3d.Distance()
+			wallSlice := subSlice{texture: lvl.CurrTex[x], src: *lvl.Cts[x], dst: *lvl.Sv[x]}
+			startY, endY := geom.ClampInt(wallSlice.dst.Min.Y, 0, c.h), geom.ClampInt(wallSlice.dst.Max.Y, 0, c.h)
+			spans := c.spriteVisibleRows(x, startY, endY, lvl.Zb[x])
 
-					if c.convergenceDistance == 0 || convergenceDistance < c.convergenceDistance {
-						c.convergenceDistance = convergenceDistance
-						c.convergencePoint = &geom3d.Vector3{X: convergenceLine3d.X2, Y: convergenceLine3d.Y2, Z: convergenceLine3d.Z2}
+			var parts, decals []subSlice
+			for _, span := range spans {
+				if part, ok := wallSlice.clipRows(span); ok {
+					parts = append(parts, part)
+				}
+				for _, d := range lvl.Decals[x] {
+					if part, ok := d.clipRows(span); ok {
+						decals = append(decals, part)
 					}
 				}
+			}
+			lvl.Decals[x] = decals
 
-				//floor texture for map coordinate being rendered
-				floorTex := c.tex.FloorTextureAt(int(currentFloorX), int(currentFloorY))
-				if floorTex == nil {
-					continue
-				}
+			if len(parts) == 0 {
+				// thin wall is hidden behind closer walls and floors
+				lvl.CurrTex[x] = nil
+				lvl.Parts[x] = nil
+				continue
+			}
+
+			//--first visible part is the slice, the rest are drawn after it--//
+			lvl.Cts[x] = &parts[0].src
+			lvl.Sv[x].Min.Y, lvl.Sv[x].Max.Y = parts[0].dst.Min.Y, parts[0].dst.Max.Y
+			lvl.Parts[x] = parts[1:]
+		}
+	}
+}
 
-				floorTexX := int(currentFloorX*float64(c.texSize)) % c.texSize
-				floorTexY := int(currentFloorY*float64(c.texSize)) % c.texSize
+// castSurfacePixel renders the pixel of a horizontal surface at the given height if it is visible,
+// returns true if nothing farther can be seen at the pixel
+func (c *Camera) castSurfacePixel(x, y int, surfaceZ, rowDiv float64, textureAt func(mapX, mapY int) *image.RGBA) bool {
+	currentDist := c.surfaceDist(surfaceZ, rowDiv)
+	if currentDist <= 0 {
+		return false
+	}
+	if currentDist > c.renderDistance || c.isWallOccluding(x, y, currentDist) {
+		return true
+	}
 
-				// buffer[y][x] = (texture[3][texWidth * floorTexY + floorTexX] >> 1) & 8355711;
-				// the same vertical slice method cannot be used for floor rendering
-				// floorTexNum := 0
-				// floorTex := c.floorLvl.texRGBA[floorTexNum]
+	//calculate ray direction to find the map position at the distance
+	cameraX := 2.0*float64(x)/float64(c.w) - 1.0 //x-coordinate in camera space
+	currentFloorX := c.pos.X + currentDist*(c.dir.X+c.plane.X*cameraX)
+	currentFloorY := c.pos.Y + currentDist*(c.dir.Y+c.plane.Y*cameraX)
 
-				//pixel := floorTex.RGBAAt(floorTexX, floorTexY)
-				pxOffset := floorTex.PixOffset(floorTexX, floorTexY)
-				if pxOffset < 0 {
-					continue
-				}
-				pixel := color.RGBA{floorTex.Pix[pxOffset],
-					floorTex.Pix[pxOffset+1],
-					floorTex.Pix[pxOffset+2],
-					floorTex.Pix[pxOffset+3]}
-
-				// lighting
-				pixelSt := &color.RGBA{255, 255, 255, 255}
-				shadowDepth := math.Sqrt(currentDist) * c.lightFalloff
-				pixelSt.R = byte(geom.ClampInt(int(float64(pixelSt.R)+shadowDepth+c.globalIllumination), int(c.minLightRGB.R), int(c.maxLightRGB.R)))
-				pixelSt.G = byte(geom.ClampInt(int(float64(pixelSt.G)+shadowDepth+c.globalIllumination), int(c.minLightRGB.G), int(c.maxLightRGB.G)))
-				pixelSt.B = byte(geom.ClampInt(int(float64(pixelSt.B)+shadowDepth+c.globalIllumination), int(c.minLightRGB.B), int(c.maxLightRGB.B)))
-				pixel.R = uint8(float64(pixel.R) * float64(pixelSt.R) / 256)
-				pixel.G = uint8(float64(pixel.G) * float64(pixelSt.G) / 256)
-				pixel.B = uint8(float64(pixel.B) * float64(pixelSt.B) / 256)
-
-				//c.horLvl.HorBuffer.SetRGBA(x, y, pixel)
-				pxOffset = c.floorLvl.horBuffer.PixOffset(x, y)
-				c.floorLvl.horBuffer.Pix[pxOffset] = pixel.R
-				c.floorLvl.horBuffer.Pix[pxOffset+1] = pixel.G
-				c.floorLvl.horBuffer.Pix[pxOffset+2] = pixel.B
-				c.floorLvl.horBuffer.Pix[pxOffset+3] = pixel.A
+	// do not call texture interfaces if X/Y is outside of map bounds
+	if currentFloorX < 0 || currentFloorY < 0 || int(currentFloorX) >= c.mapWidth || int(currentFloorY) >= c.mapHeight {
+		return false
+	}
+
+	//texture for map coordinate being rendered, nil leaves what is beyond it visible
+	//(except for flat sprites on the ground floor, which is shown with the non-repeating floor texture)
+	surfaceTex := textureAt(int(currentFloorX), int(currentFloorY))
+	if surfaceTex == nil && surfaceZ != 0 {
+		return false
+	}
+	surfaceTex, scrollX, scrollY := c.animatedFloorTextureAt(surfaceTex)
+
+	if !c.castHorizontalPixel(x, y, surfaceTex, scrollX, scrollY, currentFloorX, currentFloorY, surfaceZ, currentDist) {
+		// transparent texels leave what is beyond them visible
+		return false
+	}
+	c.floorLvl.surfaceZ[y*c.w+x] = surfaceZ
+
+	convergenceCol, convergenceRow := c.w/2-1, c.h/2-1
+	if x == convergenceCol && y == convergenceRow {
+		// use pitch angle and perpendicular distance (adjusted for fov zoom) to find Z point of convergence
+		convergencePerpDist := currentDist * c.fovDepth
+		convergenceLine3d := geom3d.Line3dFromBaseAngle(c.pos.X, c.pos.Y, c.posZ, c.headingAngle, c.pitchAngle, convergencePerpDist)
+		convergenceDistance := convergenceLine3d.Distance()
+
+		if c.convergenceDistance == -1 || convergenceDistance < c.convergenceDistance {
+			c.convergenceDistance = convergenceDistance
+			c.convergencePoint = &geom3d.Vector3{X: convergenceLine3d.X2, Y: convergenceLine3d.Y2, Z: convergenceLine3d.Z2}
+		}
+	}
+
+	if surfaceZ > 0 {
+		// the ground floor is left out of the zbuffer for sprite casting since sprites stand on it
+		c.floorLvl.zBuffer[y*c.w+x] = currentDist
+	}
+	return true
+}
+
+// surfaceDist returns the perpendicular distance of a horizontal surface at the given height seen at a screen row
+func (c *Camera) surfaceDist(surfaceZ, rowDiv float64) float64 {
+	return (2.0 * (c.posZ - surfaceZ) * float64(c.h)) / rowDiv
+}
+
+// topFace --represents the top of a wall lower than its level, where a column ray passes over it--//
+type topFace struct {
+	mapX, mapY, levelNum int
+	z                    float64
+
+	//perpendicular distances where the ray enters and leaves the map cell
+	distIn, distOut float64
+}
+
+// addTopFace records the top face of a wall block for the column if the camera is above it
+// and it is not level with the floor above (only called from the goroutine casting that level)
+func (c *Camera) addTopFace(x, levelNum, mapX, mapY int, top, distIn, distOut float64) {
+	z := float64(levelNum) + top
+	if top == 1 || c.posZ <= z {
+		return
+	}
+	c.topFaces[levelNum][x] = append(c.topFaces[levelNum][x], topFace{mapX: mapX, mapY: mapY, levelNum: levelNum, z: z, distIn: distIn, distOut: distOut})
+}
+
+// topFaceAt returns the nearest top face of a wall lower than its level seen at the screen row of the column
+func (c *Camera) topFaceAt(x int, rowDiv float64) (*topFace, float64) {
+	var nearest *topFace
+	nearestDist := math.MaxFloat64
+	for levelNum := range c.topFaces {
+		faces := c.topFaces[levelNum][x]
+		for i := range faces {
+			dist := c.surfaceDist(faces[i].z, rowDiv)
+			if dist >= faces[i].distIn && dist < faces[i].distOut && dist < nearestDist {
+				nearest, nearestDist = &faces[i], dist
+			}
+		}
+	}
+	return nearest, nearestDist
+}
+
+// hasTopFace returns true if the map cell has a wall block with its own top face below the floor of the level above
+func (c *Camera) hasTopFace(mapX, mapY, levelNum int) bool {
+	if levelNum < 0 || levelNum >= c.mapObj.NumLevels() || !c.isWallBlock(c.mapObj.Level(levelNum), mapX, mapY, levelNum) {
+		return false
+	}
+	_, top := c.cellHeight(levelNum, mapX, mapY)
+	return top != 1
+}
+
+// cellHeight returns the bottom and top of the wall in the map cell relative to the bottom of its level
+func (c *Camera) cellHeight(levelNum, mapX, mapY int) (float64, float64) {
+	if heights, ok := c.mapObj.(CellHeightMap); ok {
+		return heights.CellHeight(levelNum, mapX, mapY)
+	}
+	return 0, 1
+}
+
+// canSeePast returns true if the camera is above or below a wall with the given bottom and top within the level
+func (c *Camera) canSeePast(levelNum int, bottom, top float64) bool {
+	return c.posZ > float64(levelNum)+top || c.posZ < float64(levelNum)+bottom
+}
+
+// floorTextureAt returns the floor texture at the given map coordinates and level number
+func (c *Camera) floorTextureAt(x, y, levelNum int) *image.RGBA {
+	if levelHandler, ok := c.tex.(LevelFloorTextureHandler); ok {
+		return levelHandler.FloorTextureAtLevel(x, y, levelNum)
+	}
+
+	if levelNum == 0 {
+		return c.tex.FloorTextureAt(x, y)
+	}
+	return nil
+}
+
+// isWallOccluding returns true if a wall slice closer than the given distance is covering the screen pixel
+// (thin walls and doors are seen through)
+func (c *Camera) isWallOccluding(x, y int, dist float64) bool {
+	for _, layers := range c.levels {
+		for _, lvl := range layers {
+			if lvl.CurrTex[x] != nil && !lvl.Tw[x] && lvl.Zb[x] < dist && lvl.Sv[x].Min.Y <= y && y < lvl.Sv[x].Max.Y {
+				return true
 			}
-		}()
+		}
+	}
+	return false
+}
+
+// inBounds returns true if the map coordinates are within the map grid
+func (c *Camera) inBounds(mapX, mapY int) bool {
+	return mapX >= 0 && mapY >= 0 && mapX < c.mapWidth && mapY < c.mapHeight
+}
+
+// isWallBlock returns true if the map cell has a regular wall block filling it
+func (c *Camera) isWallBlock(grid [][]int, mapX, mapY, levelNum int) bool {
+	if grid[mapX][mapY] <= 0 || c.isPushWallOrigin(mapX, mapY, levelNum) || c.Door(mapX, mapY, levelNum) != nil {
+		return false
+	}
+
+	if thinWalls, ok := c.mapObj.(ThinWallMap); ok {
+		if orientation, _ := thinWalls.ThinWallAt(mapX, mapY, levelNum); orientation != ThinWallNone {
+			return false
+		}
+	}
+	return true
+}
+
+// castHorizontalPixel renders a single lighted pixel of a floor or ceiling texture to the horizontal buffer,
+// returns false if the pixel is transparent and nothing was rendered
+func (c *Camera) castHorizontalPixel(x, y int, tex *image.RGBA, scrollX, scrollY, worldX, worldY, worldZ, dist float64) bool {
+	var pixel color.RGBA
+	if tex != nil {
+		//a map cell spans the shorter side of the texture, longer textures tile across cells
+		texBounds := tex.Bounds()
+		texWidth, texHeight := texBounds.Dx(), texBounds.Dy()
+		texUnit := float64(geom.MinInt(texWidth, texHeight))
+		texX := texBounds.Min.X + (int(worldX*texUnit)+int(scrollX*float64(texWidth)))%texWidth
+		texY := texBounds.Min.Y + (int(worldY*texUnit)+int(scrollY*float64(texHeight)))%texHeight
+
+		// buffer[y][x] = (texture[3][texWidth * floorTexY + floorTexX] >> 1) & 8355711;
+		// the same vertical slice method cannot be used for floor rendering
+		// floorTexNum := 0
+		// floorTex := c.floorLvl.texRGBA[floorTexNum]
+
+		//pixel := tex.RGBAAt(texX, texY)
+		pxOffset := tex.PixOffset(texX, texY)
+		if pxOffset < 0 {
+			return false
+		}
+		pixel = color.RGBA{tex.Pix[pxOffset],
+			tex.Pix[pxOffset+1],
+			tex.Pix[pxOffset+2],
+			tex.Pix[pxOffset+3]}
 	}
+
+	//--flat sprites lying on the surface, lighted along with it (floors seen from above only)--//
+	if worldZ <= c.posZ {
+		pixel = c.castFloorSprites(pixel, worldX, worldY, worldZ)
+	}
+	if pixel.A == 0 {
+		return false
+	}
+
+	// lighting
+	shadowDepth := math.Sqrt(dist) * c.lightFalloff
+	//floors belong to the level above them, ceilings to the level below them
+	lightLevel := int(math.Floor(worldZ))
+	if worldZ > c.posZ {
+		lightLevel = int(math.Ceil(worldZ)) - 1
+	}
+	lights := c.illumination(worldX, worldY, worldZ)
+	lights.add(c.flashlightIllumination(worldX, worldY, worldZ, worldZ))
+	if c.lightmap != nil {
+		if worldZ > c.posZ {
+			lights.add(c.lightmap.ceilingAt(worldX, worldY, lightLevel))
+		} else {
+			// top faces of walls lower than their level use the floor above them
+			lights.add(c.lightmap.floorAt(worldX, worldY, int(math.Ceil(worldZ))))
+		}
+	}
+	pixelSt := c.lightTint(shadowDepth+c.cellIllumination(int(worldX), int(worldY), lightLevel), lights)
+	pixelSa := c.applyFog(pixelSt, dist)
+	pixel.R = uint8(float64(pixel.R) * float64(pixelSt.R) / 256)
+	pixel.G = uint8(float64(pixel.G) * float64(pixelSt.G) / 256)
+	pixel.B = uint8(float64(pixel.B) * float64(pixelSt.B) / 256)
+	if pixelSa != nil {
+		// fog color is only added to opaque pixels, the buffer is drawn with premultiplied alpha
+		fogAlpha := float64(pixel.A) / 255
+		pixel.R = byte(geom.ClampInt(int(pixel.R)+int(float64(pixelSa.R)*fogAlpha), 0, 255))
+		pixel.G = byte(geom.ClampInt(int(pixel.G)+int(float64(pixelSa.G)*fogAlpha), 0, 255))
+		pixel.B = byte(geom.ClampInt(int(pixel.B)+int(float64(pixelSa.B)*fogAlpha), 0, 255))
+	}
+
+	//c.horLvl.HorBuffer.SetRGBA(x, y, pixel)
+	pxOffset := c.floorLvl.horBuffer.PixOffset(x, y)
+	c.floorLvl.horBuffer.Pix[pxOffset] = pixel.R
+	c.floorLvl.horBuffer.Pix[pxOffset+1] = pixel.G
+	c.floorLvl.horBuffer.Pix[pxOffset+2] = pixel.B
+	c.floorLvl.horBuffer.Pix[pxOffset+3] = pixel.A
+	return true
 }
 
 func (c *Camera) castSprite(spriteOrdIndex int) {
@@ -611,6 +1061,11 @@ func (c *Camera) castSprite(spriteOrdIndex int) {
 		return
 	}
 
+	if oriented, ok := sprite.(OrientedSprite); ok {
+		c.castOrientedSprite(spriteOrdIndex, sprite, oriented.Orientation())
+		return
+	}
+
 	// track whether the sprite actually needs to draw
 	renderSprite := false
 
@@ -618,11 +1073,10 @@ func (c *Camera) castSprite(spriteOrdIndex int) {
 	spriteX := sprite.Pos().X - c.pos.X
 	spriteY := sprite.Pos().Y - c.pos.Y
 
-	spriteTex := sprite.Texture()
-	spriteTexRect := sprite.TextureRect()
+	spriteTex, spriteTexRect := getSpriteTexture(sprite, c.pos.X, c.pos.Y)
 	spriteTexWidth, spriteTexHeight := spriteTex.Bounds().Dx(), spriteTex.Bounds().Dy()
 	spriteTexRatioWH := float64(spriteTexWidth) / float64(spriteTexHeight)
-	spriteIllumination := sprite.Illumination()
+	shading := c.getSpriteShading(sprite)
 
 	//transform sprite with the inverse camera matrix
 	// [ planeX   dirX ] -1                                       [ dirY      -dirX ]
@@ -683,19 +1137,6 @@ func (c *Camera) castSprite(spriteOrdIndex int) {
 		drawEndX = c.w - 1
 	}
 
-	// used to determine if is convergence point that hit a sprite
-	canConverge := sprite.IsFocusable()
-	convergenceCol, convergenceRow := c.w/2-1, c.h/2-1
-
-	// modify tex startY and endY based on distance
-	d := (drawStartY-vMoveScreen)*256 - c.h*128 + spriteHeight*128 //256 and 128 factors to avoid floats
-	texStartY := ((d * spriteTexHeight) / spriteHeight) / 256
-
-	d = (drawEndY-1-vMoveScreen)*256 - c.h*128 + spriteHeight*128
-	texEndY := ((d * spriteTexHeight) / spriteHeight) / 256
-
-	var spriteSlices []*image.Rectangle
-
 	if !c.alwaysSetSpriteScreenRect || spriteDist <= c.renderDistance {
 		//loop through every vertical stripe of the sprite on screen
 		for stripe := drawStartX; stripe < drawEndX; stripe++ {
@@ -703,53 +1144,21 @@ func (c *Camera) castSprite(spriteOrdIndex int) {
 			//1) it's in front of camera plane so you don't see things behind you
 			//2) it's on the screen (left)
 			//3) it's on the screen (right)
-			//4) ZBuffer, with perpendicular distance
-			if transformY > 0 && stripe > 0 && stripe < c.w && transformY < c.zBuffer[stripe] {
-				var spriteLvl *level
-				if !renderSprite {
-					renderSprite = true
-					spriteLvl = c.makeSpriteLevel(spriteOrdIndex)
-					spriteSlices = makeSlices(spriteTexWidth, spriteTexHeight, spriteTexRect.Min.X, spriteTexRect.Min.Y)
-				} else {
-					spriteLvl = c.spriteLvls[spriteOrdIndex]
-				}
-
+			//4) ZBuffer of each level, with perpendicular distance
+			if transformY > 0 && stripe > 0 && stripe < c.w {
 				texX := int(256*(stripe-(-spriteWidth/2+spriteScreenX))*spriteTexWidth/spriteWidth) / 256
-				if texX < 0 || texX >= cap(spriteSlices) {
+				if texX < 0 || texX >= spriteTexWidth {
 					continue
 				}
 
-				if canConverge && stripe == convergenceCol && drawStartY <= convergenceRow && convergenceRow <= drawEndY {
-					// use pitch angle and perpendicular distance (adjusted for fov zoom) to find Z point of convergence
-					convergencePerpDist := spriteDist
-					convergenceLine3d := geom3d.Line3dFromBaseAngle(c.pos.X, c.pos.Y, c.posZ, c.headingAngle, c.pitchAngle, convergencePerpDist)
-					convergenceDistance := convergenceLine3d.Distance()
-
-					if c.convergenceDistance == -1 || convergenceDistance < c.convergenceDistance {
-						c.convergenceDistance = convergenceDistance
-						c.convergencePoint = &geom3d.Vector3{X: convergenceLine3d.X2, Y: convergenceLine3d.Y2, Z: convergenceLine3d.Z2}
-						c.convergenceSprite = sprite
-					}
+				stripeSlice := &spriteStripe{
+					x: stripe, startY: drawStartY, endY: drawEndY,
+					texX: texX, vMoveScreen: vMoveScreen, height: spriteHeight,
+					dist: transformY, convergencePerpDist: spriteDist,
+				}
+				if c.castSpriteStripe(spriteOrdIndex, sprite, stripeSlice, spriteTex, spriteTexRect, shading) {
+					renderSprite = true
 				}
-
-				//--set current texture slice--//
-				spriteLvl.Cts[stripe] = spriteSlices[texX]
-				spriteLvl.Cts[stripe].Min.Y = spriteTexRect.Min.Y + texStartY
-				spriteLvl.Cts[stripe].Max.Y = spriteTexRect.Min.Y + texEndY + 1
-
-				spriteLvl.CurrTex[stripe] = spriteTex
-
-				//--set draw start and height of slice--//
-				spriteLvl.Sv[stripe].Min.Y = drawStartY
-				spriteLvl.Sv[stripe].Max.Y = drawEndY
-
-				//// LIGHTING ////
-				// distance based lighting/shading
-				shadowDepth := math.Sqrt(transformY) * c.lightFalloff
-				spriteLvl.St[stripe] = &color.RGBA{255, 255, 255, 255}
-				spriteLvl.St[stripe].R = byte(geom.ClampInt(int(float64(spriteLvl.St[stripe].R)+shadowDepth+c.globalIllumination+spriteIllumination), int(c.minLightRGB.R), int(c.maxLightRGB.R)))
-				spriteLvl.St[stripe].G = byte(geom.ClampInt(int(float64(spriteLvl.St[stripe].G)+shadowDepth+c.globalIllumination+spriteIllumination), int(c.minLightRGB.G), int(c.maxLightRGB.G)))
-				spriteLvl.St[stripe].B = byte(geom.ClampInt(int(float64(spriteLvl.St[stripe].B)+shadowDepth+c.globalIllumination+spriteIllumination), int(c.minLightRGB.B), int(c.maxLightRGB.B)))
 			}
 		}
 	}
@@ -764,34 +1173,427 @@ func (c *Camera) castSprite(spriteOrdIndex int) {
 	}
 }
 
-func makeSlices(width, height, xOffset, yOffset int) []*image.Rectangle {
-	newSlices := make([]*image.Rectangle, width)
+// castOrientedSprite casts a flat sprite with a fixed orientation as a line segment through its position,
+// intersecting the ray of each column with it so the texture is mapped with perspective
+func (c *Camera) castOrientedSprite(spriteOrdIndex int, sprite Sprite, orientation float64) {
+	spriteDist := c.spriteDistance[spriteOrdIndex]
+	shading := c.getSpriteShading(sprite)
+
+	spriteTex, spriteTexRect := getSpriteTexture(sprite, c.pos.X, c.pos.Y)
+	spriteTexWidth, spriteTexHeight := spriteTex.Bounds().Dx(), spriteTex.Bounds().Dy()
+	spriteScale := sprite.Scale()
+
+	//the segment is as wide as a camera facing sprite of the same scale would be
+	segmentWidth := spriteScale * float64(spriteTexWidth) / float64(spriteTexHeight)
+	segmentX, segmentY := math.Cos(orientation)*segmentWidth, math.Sin(orientation)*segmentWidth
+	startX, startY := sprite.Pos().X-segmentX/2, sprite.Pos().Y-segmentY/2
+
+	//only the columns between both ends of the segment need to be cast when both are in front of the camera
+	drawStartX, drawEndX := 0, c.w
+	startCol, startOk := c.projectColumn(startX, startY)
+	endCol, endOk := c.projectColumn(startX+segmentX, startY+segmentY)
+	if startOk && endOk {
+		drawStartX = geom.ClampInt(geom.MinInt(startCol, endCol), 0, c.w)
+		drawEndX = geom.ClampInt(geom.MaxInt(startCol, endCol)+1, 0, c.w)
+	}
+
+	var vOffset float64 = getAnchorVerticalOffset(sprite.VerticalAnchor(), spriteScale, c.h)
+	var vMove float64 = -sprite.PosZ()*float64(c.h) + vOffset
+
+	renderSprite := false
+	var spriteCastRect *image.Rectangle
+
+	for stripe := drawStartX; stripe < drawEndX; stripe++ {
+		//intersect the ray of the column with the segment, t is the perpendicular distance and s is along the segment
+		cameraX := 2.0*float64(stripe)/float64(c.w) - 1.0
+		rayDirX := c.dir.X + c.plane.X*cameraX
+		rayDirY := c.dir.Y + c.plane.Y*cameraX
+
+		denom := rayDirX*segmentY - rayDirY*segmentX
+		if denom == 0 {
+			// seen edge-on
+			continue
+		}
+		dX, dY := startX-c.pos.X, startY-c.pos.Y
+		t := (dX*segmentY - dY*segmentX) / denom
+		s := (dX*rayDirY - dY*rayDirX) / denom
+		if t <= 0 || s < 0 || s >= 1 || t > c.renderDistance {
+			continue
+		}
+
+		//calculate height and vertical position of the stripe on screen at its distance
+		vMoveScreen := int(vMove/t) + c.pitch + int(c.camZ/t)
+		spriteHeight := int(float64(c.h) / t * spriteScale)
+		if spriteHeight == 0 {
+			continue
+		}
+
+		drawStartY := geom.ClampInt(-spriteHeight/2+c.h/2+vMoveScreen, 0, c.h-1)
+		drawEndY := geom.ClampInt(spriteHeight/2+c.h/2+vMoveScreen, 0, c.h-1)
+
+		stripeRect := image.Rect(stripe, drawStartY, stripe+1, drawEndY)
+		if spriteCastRect == nil {
+			spriteCastRect = &stripeRect
+		} else {
+			*spriteCastRect = spriteCastRect.Union(stripeRect)
+		}
+
+		stripeSlice := &spriteStripe{
+			x: stripe, startY: drawStartY, endY: drawEndY,
+			texX: int(s * float64(spriteTexWidth)), vMoveScreen: vMoveScreen, height: spriteHeight,
+			dist: t, convergencePerpDist: t * c.fovDepth,
+		}
+		if c.castSpriteStripe(spriteOrdIndex, sprite, stripeSlice, spriteTex, spriteTexRect, shading) {
+			renderSprite = true
+		}
+	}
+
+	if renderSprite || (c.alwaysSetSpriteScreenRect && spriteCastRect != nil && spriteDist <= c.renderDistance) {
+		// store raycasted sprite x/y view bounds so they can be retrieved by consumers
+		sprite.SetScreenRect(spriteCastRect)
+	} else {
+		c.clearSpriteLevel(spriteOrdIndex)
+		sprite.SetScreenRect(nil)
+	}
+}
+
+// Project returns the screen coordinates and perpendicular distance (depth) of a 3-Dimensional point in the map,
+// using the same projection as sprites with a center anchor. The screen coordinates can be off screen,
+// visible is false if the point is behind the camera, off screen, beyond the render distance,
+// or occluded by walls, floors or ceilings rendered in front of it as of the last Update.
+func (c *Camera) Project(p *geom3d.Vector3) (x, y int, depth float64, visible bool) {
+	//translate point position to relative to camera
+	pointX := p.X - c.pos.X
+	pointY := p.Y - c.pos.Y
+
+	//transform point with the inverse camera matrix
+	invDet := 1.0 / (c.plane.X*c.dir.Y - c.dir.X*c.plane.Y)
+
+	transformX := invDet * (c.dir.Y*pointX - c.dir.X*pointY)
+	transformY := invDet * (-c.plane.Y*pointX + c.plane.X*pointY)
+	if transformY <= 0 {
+		// behind the camera
+		return 0, 0, transformY, false
+	}
+
+	x = int(float64(c.w) / 2 * (1 + transformX/transformY))
+
+	vMove := -p.Z*float64(c.h) + float64(c.h)/2
+	vMoveScreen := int(vMove/transformY) + c.pitch + int(c.camZ/transformY)
+	y = c.h/2 + vMoveScreen
+
+	if x < 0 || y < 0 || x >= c.w || y >= c.h || transformY > c.renderDistance {
+		return x, y, transformY, false
+	}
+
+	//occlusion by the zbuffer of walls, and of floors and ceilings other than the ground floor
+	occluded := c.isWallOccluding(x, y, transformY) || c.floorLvl.zBuffer[y*c.w+x] < transformY
+	return x, y, transformY, !occluded
+}
+
+// spriteStripe --projection of a sprite onto a single screen column--//
+type spriteStripe struct {
+	// x screen column, startY and endY rows of the stripe before it is clipped
+	x, startY, endY int
+
+	// texX column of the sprite texture
+	texX int
+
+	// vMoveScreen vertical offset and height of the whole sprite on screen at the distance of the stripe
+	vMoveScreen, height int
+
+	// dist perpendicular distance of the stripe, convergencePerpDist used to find the point of convergence on it
+	dist, convergencePerpDist float64
+}
+
+// castSpriteStripe clips a stripe of the sprite against what is in front of it and sets its slice in the sprite level,
+// returns false if the stripe is completely hidden
+func (c *Camera) castSpriteStripe(spriteOrdIndex int, sprite Sprite, stripe *spriteStripe, spriteTex *ebiten.Image, spriteTexRect image.Rectangle, shading *spriteShading) bool {
+	spans := c.spriteVisibleRows(stripe.x, stripe.startY, stripe.endY, stripe.dist)
+	if len(spans) == 0 {
+		// stripe is hidden behind walls
+		return false
+	}
+
+	spriteLvl := c.spriteLvls[spriteOrdIndex]
+	if spriteLvl == nil {
+		spriteLvl = c.makeSpriteLevel(spriteOrdIndex)
+		spriteLvl.Blend = shading.blend
+	}
+
+	// used to determine if is convergence point that hit a sprite
+	convergenceCol, convergenceRow := c.w/2-1, c.h/2-1
+	if sprite.IsFocusable() && stripe.x == convergenceCol && isRowInSpans(spans, convergenceRow) {
+		// use pitch angle and perpendicular distance (adjusted for fov zoom) to find Z point of convergence
+		convergenceLine3d := geom3d.Line3dFromBaseAngle(c.pos.X, c.pos.Y, c.posZ, c.headingAngle, c.pitchAngle, stripe.convergencePerpDist)
+		convergenceDistance := convergenceLine3d.Distance()
+
+		if c.convergenceDistance == -1 || convergenceDistance < c.convergenceDistance {
+			c.convergenceDistance = convergenceDistance
+			c.convergencePoint = &geom3d.Vector3{X: convergenceLine3d.X2, Y: convergenceLine3d.Y2, Z: convergenceLine3d.Z2}
+			c.convergenceSprite = sprite
+		}
+	}
+
+	// modify tex startY and endY based on distance
+	spriteTexHeight := spriteTex.Bounds().Dy()
+	texRowAt := func(y int) int {
+		d := (y-stripe.vMoveScreen)*256 - c.h*128 + stripe.height*128 //256 and 128 factors to avoid floats
+		return ((d * spriteTexHeight) / stripe.height) / 256
+	}
+
+	//--texture slice clipped to the visible rows of a span of the stripe--//
+	sliceRect := func(span rowSpan) image.Rectangle {
+		return image.Rect(
+			spriteTexRect.Min.X+stripe.texX, spriteTexRect.Min.Y+texRowAt(span.start),
+			spriteTexRect.Min.X+stripe.texX+1, spriteTexRect.Min.Y+texRowAt(span.end-1)+1,
+		)
+	}
+
+	//--set current texture slice to the first span--//
+	firstRect := sliceRect(spans[0])
+	spriteLvl.Cts[stripe.x] = &firstRect
+
+	spriteLvl.CurrTex[stripe.x] = spriteTex
+
+	//--set draw start and height of slice--//
+	spriteLvl.Sv[stripe.x].Min.Y = spans[0].start
+	spriteLvl.Sv[stripe.x].Max.Y = spans[0].end
+
+	//--spans below closer walls covering the middle of the stripe--//
+	spriteLvl.Parts[stripe.x] = spriteLvl.Parts[stripe.x][:0]
+	for _, span := range spans[1:] {
+		spriteLvl.Parts[stripe.x] = append(spriteLvl.Parts[stripe.x], subSlice{
+			texture: spriteTex,
+			src:     sliceRect(span),
+			dst:     image.Rect(stripe.x, span.start, stripe.x+1, span.end),
+		})
+	}
+
+	//// LIGHTING ////
+	spriteLvl.St[stripe.x], spriteLvl.Sa[stripe.x] = c.spriteStripeTint(shading, stripe.dist)
+	spriteLvl.Zb[stripe.x] = stripe.dist
+
+	return true
+}
+
+// isRowInSpans returns true if the row is within any of the spans
+func isRowInSpans(spans []rowSpan, y int) bool {
+	for _, span := range spans {
+		if span.start <= y && y < span.end {
+			return true
+		}
+	}
+	return false
+}
+
+// projectColumn returns the screen column of a map position, or false if it is behind the camera
+func (c *Camera) projectColumn(x, y float64) (int, bool) {
+	invDet := 1.0 / (c.plane.X*c.dir.Y - c.dir.X*c.plane.Y)
+	transformX := invDet * (c.dir.Y*(x-c.pos.X) - c.dir.X*(y-c.pos.Y))
+	transformY := invDet * (-c.plane.Y*(x-c.pos.X) + c.plane.X*(y-c.pos.Y))
+	if transformY <= 0 {
+		return 0, false
+	}
+	return int(float64(c.w) / 2 * (1 + transformX/transformY)), true
+}
+
+// spriteShading --lighting, coloring, translucency and blending shared by each stripe of a sprite--//
+type spriteShading struct {
+	illumination float64
+	lights       lightRGB
+	tint         *color.NRGBA
+	flash        *color.NRGBA
+	opacity      float64
+	blend        ebiten.Blend
+}
+
+// getSpriteShading gets the lighting at the position of the sprite, and its optional coloring, translucency and blending
+func (c *Camera) getSpriteShading(sprite Sprite) *spriteShading {
+	pos, posZ := sprite.Pos(), sprite.PosZ()
+	shading := &spriteShading{opacity: 1.0, blend: ebiten.BlendSourceOver}
+
+	shading.illumination = sprite.Illumination() + c.cellIllumination(int(pos.X), int(pos.Y), int(math.Floor(posZ)))
+	shading.lights = c.illumination(pos.X, pos.Y, posZ)
+	shading.lights.add(c.flashlightIllumination(pos.X, pos.Y, posZ, posZ))
+	if c.lightmap != nil {
+		shading.lights.add(c.lightmap.floorAt(pos.X, pos.Y, int(math.Floor(posZ))))
+	}
+
+	if tinted, ok := sprite.(TintedSprite); ok {
+		tint, flash := tinted.Tint(), tinted.Flash()
+		shading.tint = &tint
+		if flash.A > 0 {
+			shading.flash = &flash
+		}
+	}
+
+	if translucent, ok := sprite.(TranslucentSprite); ok {
+		shading.opacity = geom.Clamp(translucent.Opacity(), 0, 1)
+		shading.blend = translucent.BlendMode()
+	}
+	return shading
+}
+
+// spriteStripeTint returns the tint and fog color of a sprite stripe at the given perpendicular distance
+func (c *Camera) spriteStripeTint(shading *spriteShading, dist float64) (*color.RGBA, *color.RGBA) {
+	// distance based lighting/shading
+	shadowDepth := math.Sqrt(dist) * c.lightFalloff
+	tint := c.lightTint(shadowDepth+shading.illumination, shading.lights)
+	tint.A = byte(shading.opacity * 255)
+
+	if shading.tint != nil {
+		tint.R = byte(uint32(tint.R) * uint32(shading.tint.R) / 255)
+		tint.G = byte(uint32(tint.G) * uint32(shading.tint.G) / 255)
+		tint.B = byte(uint32(tint.B) * uint32(shading.tint.B) / 255)
+	}
+
+	if shading.flash == nil {
+		return tint, c.applyFog(tint, dist)
+	}
+
+	// flash color replaces the lighted color by its alpha, then is faded into fog the same as the sprite
+	f := shading.flash
+	tint.R = byte(uint32(tint.R) * uint32(255-f.A) / 255)
+	tint.G = byte(uint32(tint.G) * uint32(255-f.A) / 255)
+	tint.B = byte(uint32(tint.B) * uint32(255-f.A) / 255)
+
+	visible := 1 - c.fogAmount(dist)
+	add := &color.RGBA{
+		R: byte(float64(uint32(f.R)*uint32(f.A)/255) * visible),
+		G: byte(float64(uint32(f.G)*uint32(f.A)/255) * visible),
+		B: byte(float64(uint32(f.B)*uint32(f.A)/255) * visible),
+		A: 255,
+	}
+	if fog := c.applyFog(tint, dist); fog != nil {
+		add.R += fog.R
+		add.G += fog.G
+		add.B += fog.B
+	}
+	return tint, add
+}
+
+// spriteVisibleRows clips the rows of a sprite stripe, as projected from its Z-position and height,
+// against the walls of each level and the floors of upper levels that are in front of it,
+// returning the spans of rows left visible from top to bottom
+func (c *Camera) spriteVisibleRows(x, startY, endY int, spriteDist float64) []rowSpan {
+	spans := []rowSpan{{start: startY, end: endY}}
+
+	// walls in front of the sprite can hide any rows of the stripe, splitting it into the spans around them
+	for _, layers := range c.levels {
+		for _, lvl := range layers {
+			if lvl.CurrTex[x] == nil || lvl.Tw[x] || lvl.Zb[x] >= spriteDist {
+				// thin walls and doors are drawn in order of depth with the sprites instead
+				continue
+			}
+
+			wallStartY, wallEndY := lvl.Sv[x].Min.Y, lvl.Sv[x].Max.Y
+			clipped := make([]rowSpan, 0, len(spans)+1)
+			for _, span := range spans {
+				if wallEndY <= span.start || wallStartY >= span.end {
+					// wall does not overlap the span
+					clipped = append(clipped, span)
+					continue
+				}
+				if span.start < wallStartY {
+					clipped = append(clipped, rowSpan{start: span.start, end: wallStartY})
+				}
+				if wallEndY < span.end {
+					clipped = append(clipped, rowSpan{start: wallEndY, end: span.end})
+				}
+			}
+			spans = clipped
+		}
+	}
+
+	// floors of upper levels and ceilings in front of the sprite
+	visible := make([]rowSpan, 0, len(spans))
+	for _, span := range spans {
+		runStart := -1
+		for y := span.start; y <= span.end; y++ {
+			inFront := y == span.end || y < 0 || y >= c.h || c.floorLvl.zBuffer[y*c.w+x] < spriteDist
+			if !inFront && runStart < 0 {
+				runStart = y
+			} else if inFront && runStart >= 0 {
+				visible = append(visible, rowSpan{start: runStart, end: y})
+				runStart = -1
+			}
+		}
+	}
+
+	return visible
+}
+
+// castThinWall finds the perpendicular distance and wallX of a thin wall if the ray crosses it within the given map cell
+// credit : https://lodev.org/cgtutor/raycasting4.html#Thin
+func castThinWall(rayPosX, rayPosY, rayDirX, rayDirY float64, mapX, mapY int, orientation ThinWallOrientation, offset float64) (float64, float64, int, bool) {
+	var perpWallDist, wallPos float64
+	var side, wallCell int
+
+	switch orientation {
+	case ThinWallX:
+		if rayDirX == 0 {
+			return 0, 0, 0, false
+		}
+		side = 0
+		perpWallDist = (float64(mapX) + offset - rayPosX) / rayDirX
+		wallPos = rayPosY + perpWallDist*rayDirY
+		wallCell = mapY
+	case ThinWallY:
+		if rayDirY == 0 {
+			return 0, 0, 0, false
+		}
+		side = 1
+		perpWallDist = (float64(mapY) + offset - rayPosY) / rayDirY
+		wallPos = rayPosX + perpWallDist*rayDirX
+		wallCell = mapX
+	default:
+		return 0, 0, 0, false
+	}
 
-	//--loop through creating a "slice" for each texture x--//
-	for x := 0; x < width; x++ {
-		// xOffset/yOffset represent sprite sheet source offsets for texture
-		thisRect := image.Rect(xOffset+x, yOffset, xOffset+x+1, yOffset+height)
-		newSlices[x] = &thisRect
+	// the wall is only hit if in front of the camera and the ray crosses it inside of the same map cell
+	if perpWallDist <= 0 || int(math.Floor(wallPos)) != wallCell {
+		return 0, 0, 0, false
 	}
 
-	return newSlices
+	return perpWallDist, wallPos - float64(wallCell), side, true
 }
 
-// creates level slices for raycasting each level
-func (c *Camera) createLevels(numLevels int) []*level {
-	levelArr := make([]*level, numLevels)
+// creates level slices for raycasting each level, starting with a single layer
+func (c *Camera) createLevels(numLevels int) [][]*level {
+	levelArr := make([][]*level, numLevels)
 
 	for i := 0; i < numLevels; i++ {
-		levelArr[i] = new(level)
-		levelArr[i].Sv = sliceView(c.w, c.h)
-		levelArr[i].Cts = make([]*image.Rectangle, c.w)
-		levelArr[i].St = make([]*color.RGBA, c.w)
-		levelArr[i].CurrTex = make([]*ebiten.Image, c.w)
+		levelArr[i] = []*level{c.createLevel()}
 	}
 
 	return levelArr
 }
 
+func (c *Camera) createLevel() *level {
+	lvl := new(level)
+	lvl.Sv = sliceView(c.w, c.h)
+	lvl.Cts = make([]*image.Rectangle, c.w)
+	lvl.St = make([]*color.RGBA, c.w)
+	lvl.Sa = make([]*color.RGBA, c.w)
+	lvl.CurrTex = make([]*ebiten.Image, c.w)
+	lvl.Zb = make([]float64, c.w)
+	lvl.Cf = make([]cellFace, c.w)
+	lvl.Parts = make([][]subSlice, c.w)
+	lvl.Tw = make([]bool, c.w)
+	lvl.Decals = make([][]subSlice, c.w)
+	return lvl
+}
+
+// gets the layer of level slices for wall hits seen past closer walls, adding it if needed
+// (only called from the goroutine casting that level)
+func (c *Camera) levelLayer(levelNum, layer int) *level {
+	for len(c.levels[levelNum]) <= layer {
+		c.levels[levelNum] = append(c.levels[levelNum], c.createLevel())
+	}
+	return c.levels[levelNum][layer]
+}
+
 // creates floor slices for raycasting floor
 func (c *Camera) createFloorLevel() *horLevel {
 	horizontalLevel := new(horLevel)
@@ -823,7 +1625,10 @@ func (c *Camera) makeSpriteLevel(spriteOrdIndex int) *level {
 	spriteLvl.Sv = sliceView(c.w, c.h)
 	spriteLvl.Cts = make([]*image.Rectangle, c.w)
 	spriteLvl.St = make([]*color.RGBA, c.w)
+	spriteLvl.Sa = make([]*color.RGBA, c.w)
 	spriteLvl.CurrTex = make([]*ebiten.Image, c.w)
+	spriteLvl.Zb = make([]float64, c.w)
+	spriteLvl.Parts = make([][]subSlice, c.w)
 
 	c.spriteLvls[spriteOrdIndex] = spriteLvl
 
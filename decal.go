@@ -0,0 +1,96 @@
+package raycaster
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Decal represents an image overlaid on a wall face, such as a bullet hole, blood, a poster, or a switch
+type Decal struct {
+	// Image of the decal, can be a sub-image of a sprite sheet
+	Image *ebiten.Image
+
+	// U, V position of the top-left corner of the decal on the wall face as viewed from outside,
+	// from 0.0 (left, top) to 1.0 (right, bottom)
+	U, V float64
+
+	// Width, Height size of the decal as a fraction of the wall face
+	Width, Height float64
+}
+
+// NewDecal creates a decal placed at the given position and size on a wall face
+func NewDecal(image *ebiten.Image, u, v, width, height float64) *Decal {
+	return &Decal{Image: image, U: u, V: v, Width: width, Height: height}
+}
+
+// cellFace is a face of the wall in a map cell, used to key decals and to pick walls
+type cellFace struct {
+	cell mapCell
+	face WallFace
+}
+
+// AddDecal registers a decal on the face of the wall at the given map coordinates and level number,
+// decals added later are drawn over earlier ones
+func (c *Camera) AddDecal(x, y, levelNum int, face WallFace, decal *Decal) {
+	key := cellFace{cell: mapCell{x: x, y: y, levelNum: levelNum}, face: face}
+	if c.decals == nil {
+		c.decals = make(map[cellFace][]*Decal)
+	}
+	c.decals[key] = append(c.decals[key], decal)
+}
+
+// RemoveDecal unregisters a decal from the face of the wall at the given map coordinates and level number
+func (c *Camera) RemoveDecal(x, y, levelNum int, face WallFace, decal *Decal) {
+	key := cellFace{cell: mapCell{x: x, y: y, levelNum: levelNum}, face: face}
+	decals := c.decals[key]
+	for i, d := range decals {
+		if d == decal {
+			decals = append(decals[:i], decals[i+1:]...)
+			break
+		}
+	}
+
+	if len(decals) == 0 {
+		delete(c.decals, key)
+	} else {
+		c.decals[key] = decals
+	}
+}
+
+// Decals returns the decals registered on the face of the wall at the given map coordinates and level number
+func (c *Camera) Decals(x, y, levelNum int, face WallFace) []*Decal {
+	return c.decals[cellFace{cell: mapCell{x: x, y: y, levelNum: levelNum}, face: face}]
+}
+
+// castDecals sets the slices of decals on the wall face that are drawn over the wall slice of the column,
+// u is the position of the column on the face as viewed from outside
+func (c *Camera) castDecals(lvl *level, x, mapX, mapY, levelNum int, face WallFace, u float64) {
+	lvl.Decals[x] = lvl.Decals[x][:0]
+	if len(c.decals) == 0 {
+		return
+	}
+
+	wallRect := lvl.Sv[x]
+	wallHeight := float64(wallRect.Dy())
+	for _, d := range c.Decals(mapX, mapY, levelNum, face) {
+		if d.Image == nil || d.Width <= 0 || u < d.U || u >= d.U+d.Width {
+			continue
+		}
+
+		bounds := d.Image.Bounds()
+		texX := int((u - d.U) / d.Width * float64(bounds.Dx()))
+		if texX >= bounds.Dx() {
+			continue
+		}
+
+		lvl.Decals[x] = append(lvl.Decals[x], subSlice{
+			texture: d.Image,
+			src:     image.Rect(bounds.Min.X+texX, bounds.Min.Y, bounds.Min.X+texX+1, bounds.Max.Y),
+			dst: image.Rect(
+				wallRect.Min.X, wallRect.Min.Y+int(d.V*wallHeight),
+				wallRect.Max.X, wallRect.Min.Y+int((d.V+d.Height)*wallHeight),
+			),
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package raycaster
+
+import (
+	"math"
+)
+
+// positions this close to the edge of a map cell are treated as within it
+const cellEpsilon = 1e-9
+
+type DoorType int
+
+const (
+	// DoorSliding slides the door panel sideways into the edge of its map cell
+	DoorSliding DoorType = iota
+	// DoorSwinging swings the door panel open on a hinge at the edge of its map cell
+	DoorSwinging
+)
+
+type DoorEdge int
+
+const (
+	// DoorEdgeMin is the edge of the map cell at the lower coordinate along the door
+	DoorEdgeMin DoorEdge = iota
+	// DoorEdgeMax is the edge of the map cell at the higher coordinate along the door
+	DoorEdgeMax
+)
+
+// Door represents the state of an animated door rendered as a thin wall inside of a map cell
+type Door struct {
+	// Type determines whether the door slides or swings open
+	Type DoorType
+
+	// Orientation of the closed door within the map cell (ThinWallX or ThinWallY),
+	// sliding doors slide along the same axis the door is placed along
+	Orientation ThinWallOrientation
+
+	// Offset of the closed door within the map cell (0.0 to 1.0, 0.5 for the midline),
+	// swinging doors are placed on the cell edge nearest to it so the panel can swing open inside of the cell
+	Offset float64
+
+	// Edge of the map cell that the door slides into or swings on
+	Edge DoorEdge
+
+	// Open fraction of the door from 0.0 (closed) to 1.0 (fully open)
+	Open float64
+}
+
+// mapCell is used to key objects registered at map coordinates and level number
+type mapCell struct {
+	x, y, levelNum int
+}
+
+// NewDoor creates a closed sliding door placed at the midline of a map cell
+func NewDoor(orientation ThinWallOrientation) *Door {
+	return &Door{Type: DoorSliding, Orientation: orientation, Offset: 0.5, Edge: DoorEdgeMin}
+}
+
+// SetDoor registers a door at the given map coordinates and level number (nil to remove it).
+// The map cell still needs to have a wall present, which provides the door texture.
+func (c *Camera) SetDoor(x, y, levelNum int, door *Door) {
+	cell := mapCell{x: x, y: y, levelNum: levelNum}
+	if door == nil {
+		delete(c.doors, cell)
+		return
+	}
+
+	if c.doors == nil {
+		c.doors = make(map[mapCell]*Door)
+	}
+	c.doors[cell] = door
+}
+
+// Door returns the door registered at the given map coordinates and level number (nil if there is no door)
+func (c *Camera) Door(x, y, levelNum int) *Door {
+	return c.doors[mapCell{x: x, y: y, levelNum: levelNum}]
+}
+
+// cast finds the perpendicular distance and wallX where the ray hits the door panel within the given map cell
+func (d *Door) cast(rayPosX, rayPosY, rayDirX, rayDirY float64, mapX, mapY int) (float64, float64, int, bool) {
+	open := math.Max(0, math.Min(1, d.Open))
+
+	if d.Type == DoorSwinging {
+		return d.castSwinging(rayPosX, rayPosY, rayDirX, rayDirY, mapX, mapY, open)
+	}
+
+	perpWallDist, wallX, side, hit := castThinWall(rayPosX, rayPosY, rayDirX, rayDirY, mapX, mapY, d.Orientation, d.Offset)
+	if !hit {
+		return 0, 0, 0, false
+	}
+
+	// offset the texture with the panel as it slides, letting the ray pass through the open part
+	switch d.Edge {
+	case DoorEdgeMin:
+		if wallX >= 1-open {
+			return 0, 0, 0, false
+		}
+		wallX += open
+	case DoorEdgeMax:
+		if wallX < open {
+			return 0, 0, 0, false
+		}
+		wallX -= open
+	}
+
+	return perpWallDist, wallX, side, true
+}
+
+// castSwinging intersects the ray with the door panel rotated about its hinge on the cell edge nearest to Offset,
+// swinging into the map cell so the full length of the panel stays inside of it
+func (d *Door) castSwinging(rayPosX, rayPosY, rayDirX, rayDirY float64, mapX, mapY int, open float64) (float64, float64, int, bool) {
+	// closed panel direction from the hinge along the door
+	closedDir := 1.0
+	hingeAlong := 0.0
+	if d.Edge == DoorEdgeMax {
+		closedDir = -1.0
+		hingeAlong = 1.0
+	}
+
+	// a panel as long as the cell is wide only fits when hinged on the cell edge
+	offset, swingDir := 0.0, 1.0
+	if d.Offset >= 0.5 {
+		offset, swingDir = 1.0, -1.0
+	}
+
+	angle := open * math.Pi / 2
+	along, across := closedDir*math.Cos(angle), swingDir*math.Sin(angle)
+
+	var hingeX, hingeY, panelX, panelY float64
+	switch d.Orientation {
+	case ThinWallX:
+		hingeX, hingeY = float64(mapX)+offset, float64(mapY)+hingeAlong
+		panelX, panelY = across, along
+	case ThinWallY:
+		hingeX, hingeY = float64(mapX)+hingeAlong, float64(mapY)+offset
+		panelX, panelY = along, across
+	default:
+		return 0, 0, 0, false
+	}
+
+	// ray to line segment intersection: rayPos + t*rayDir = hinge + u*panel
+	denom := rayDirX*panelY - rayDirY*panelX
+	if denom == 0 {
+		return 0, 0, 0, false
+	}
+
+	toHingeX, toHingeY := hingeX-rayPosX, hingeY-rayPosY
+	perpWallDist := (toHingeX*panelY - toHingeY*panelX) / denom
+	u := (toHingeX*rayDirY - toHingeY*rayDirX) / denom
+	if perpWallDist <= 0 || u <= 0 || u >= 1 {
+		return 0, 0, 0, false
+	}
+
+	// the panel is clipped at the bounds of its map cell, including a panel lying on the cell edge
+	hitX, hitY := rayPosX+perpWallDist*rayDirX, rayPosY+perpWallDist*rayDirY
+	if !isInCell(hitX, mapX) || !isInCell(hitY, mapY) {
+		return 0, 0, 0, false
+	}
+
+	// side is the axis the panel faces as it turns, a panel lying along Y faces the X-axis
+	side := 0
+	if math.Abs(panelX) > math.Abs(panelY) {
+		side = 1
+	}
+
+	// wallX is measured along the closed door so the texture moves with the panel
+	wallX := u
+	if d.Edge == DoorEdgeMax {
+		wallX = 1 - u
+	}
+
+	return perpWallDist, wallX, side, true
+}
+
+// isInCell returns true if the position along an axis is within the map cell, allowing for rounding at its edges
+func isInCell(pos float64, cell int) bool {
+	return pos >= float64(cell)-cellEpsilon && pos <= float64(cell+1)+cellEpsilon
+}
@@ -0,0 +1,132 @@
+package raycaster
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDoorCast(t *testing.T) {
+	tests := []struct {
+		name             string
+		door             Door
+		rayPosX, rayPosY float64
+		rayDirX, rayDirY float64
+		mapX, mapY       int
+		hit              bool
+		perpWallDist     float64
+		wallX            float64
+		side             int
+	}{
+		{
+			name:    "sliding closed at the midline",
+			door:    Door{Type: DoorSliding, Orientation: ThinWallX, Offset: 0.5},
+			rayPosX: 0.5, rayPosY: 2.25, rayDirX: 1, rayDirY: 0,
+			mapX: 3, mapY: 2,
+			hit: true, perpWallDist: 3, wallX: 0.25, side: 0,
+		},
+		{
+			name:    "sliding half open into the min edge",
+			door:    Door{Type: DoorSliding, Orientation: ThinWallX, Offset: 0.5, Open: 0.5},
+			rayPosX: 0.5, rayPosY: 2.25, rayDirX: 1, rayDirY: 0,
+			mapX: 3, mapY: 2,
+			hit: true, perpWallDist: 3, wallX: 0.75, side: 0,
+		},
+		{
+			name:    "sliding half open through the open part",
+			door:    Door{Type: DoorSliding, Orientation: ThinWallX, Offset: 0.5, Open: 0.5},
+			rayPosX: 0.5, rayPosY: 2.75, rayDirX: 1, rayDirY: 0,
+			mapX: 3, mapY: 2,
+			hit: false,
+		},
+		{
+			name:    "sliding half open into the max edge",
+			door:    Door{Type: DoorSliding, Orientation: ThinWallY, Offset: 0.25, Edge: DoorEdgeMax, Open: 0.5},
+			rayPosX: 3.75, rayPosY: 0.5, rayDirX: 0, rayDirY: 1,
+			mapX: 3, mapY: 2,
+			hit: true, perpWallDist: 1.75, wallX: 0.25, side: 1,
+		},
+		{
+			name:    "swinging closed on the nearest max cell edge",
+			door:    Door{Type: DoorSwinging, Orientation: ThinWallX, Offset: 0.5},
+			rayPosX: 0.5, rayPosY: 2.25, rayDirX: 1, rayDirY: 0,
+			mapX: 3, mapY: 2,
+			hit: true, perpWallDist: 3.5, wallX: 0.25, side: 0,
+		},
+		{
+			name:    "swinging closed on the nearest min cell edge",
+			door:    Door{Type: DoorSwinging, Orientation: ThinWallX, Offset: 0.2},
+			rayPosX: 0.5, rayPosY: 2.25, rayDirX: 1, rayDirY: 0,
+			mapX: 3, mapY: 2,
+			hit: true, perpWallDist: 2.5, wallX: 0.25, side: 0,
+		},
+		{
+			name:    "swinging fully open inside of the map cell",
+			door:    Door{Type: DoorSwinging, Orientation: ThinWallX, Offset: 0.5, Open: 1},
+			rayPosX: 3.1, rayPosY: 0.5, rayDirX: 0, rayDirY: 1,
+			mapX: 3, mapY: 2,
+			hit: true, perpWallDist: 1.5, wallX: 0.9, side: 1,
+		},
+		{
+			name:    "swinging a third open on the max edge",
+			door:    Door{Type: DoorSwinging, Orientation: ThinWallX, Edge: DoorEdgeMax, Open: 1.0 / 3},
+			rayPosX: 0.5, rayPosY: 2.5, rayDirX: 1, rayDirY: 0,
+			mapX: 3, mapY: 2,
+			hit: true, perpWallDist: 2.5 + 0.5/math.Sqrt(3), wallX: 1 - 1/math.Sqrt(3), side: 0,
+		},
+		{
+			name:    "swinging fully open through the doorway",
+			door:    Door{Type: DoorSwinging, Orientation: ThinWallY, Offset: 0.5, Open: 1},
+			rayPosX: 3.5, rayPosY: 0.5, rayDirX: 0, rayDirY: 1,
+			mapX: 3, mapY: 2,
+			hit: false,
+		},
+		{
+			name:    "swinging behind the ray",
+			door:    Door{Type: DoorSwinging, Orientation: ThinWallX, Offset: 0.5},
+			rayPosX: 5.5, rayPosY: 2.5, rayDirX: 1, rayDirY: 0,
+			mapX: 3, mapY: 2,
+			hit: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perpWallDist, wallX, side, hit := tt.door.cast(tt.rayPosX, tt.rayPosY, tt.rayDirX, tt.rayDirY, tt.mapX, tt.mapY)
+			if hit != tt.hit {
+				t.Fatalf("hit = %v, want %v", hit, tt.hit)
+			}
+			if !hit {
+				return
+			}
+			if math.Abs(perpWallDist-tt.perpWallDist) > 1e-9 || math.Abs(wallX-tt.wallX) > 1e-9 || side != tt.side {
+				t.Errorf("got (%v, %v, %v), want (%v, %v, %v)", perpWallDist, wallX, side, tt.perpWallDist, tt.wallX, tt.side)
+			}
+		})
+	}
+}
+
+func TestDoorCastSwingingStaysInCell(t *testing.T) {
+	for _, offset := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		for _, edge := range []DoorEdge{DoorEdgeMin, DoorEdgeMax} {
+			for _, orientation := range []ThinWallOrientation{ThinWallX, ThinWallY} {
+				for step := 0; step <= 8; step++ {
+					d := &Door{Type: DoorSwinging, Orientation: orientation, Offset: offset, Edge: edge, Open: float64(step) / 8}
+
+					// rays along both axes sweeping across the map cell find the whole length of the panel inside of it
+					minWallX, maxWallX := 1.0, 0.0
+					for i := 0; i < 100; i++ {
+						pos := 3 + (float64(i)+0.5)/100
+						for _, ray := range [][4]float64{{0.5, pos, 1, 0}, {pos, 0.5, 0, 1}} {
+							if _, wallX, _, hit := d.cast(ray[0], ray[1], ray[2], ray[3], 3, 3); hit {
+								minWallX, maxWallX = math.Min(minWallX, wallX), math.Max(maxWallX, wallX)
+							}
+						}
+					}
+					if minWallX > 0.1 || maxWallX < 0.9 {
+						t.Errorf("offset %v, edge %v, orientation %v, open %v: panel cut off to (%v, %v)", offset, edge, orientation, d.Open, minWallX, maxWallX)
+					}
+				}
+			}
+		}
+	}
+}
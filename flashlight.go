@@ -0,0 +1,82 @@
+package raycaster
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+// Flashlight represents a spotlight cone carried by the camera, aimed with its heading and pitch angles
+type Flashlight struct {
+	// InnerAngle, OuterAngle of the cone from its center (radians), the light is at full intensity
+	// within the inner angle and fades out completely at the outer angle
+	InnerAngle, OuterAngle float64
+
+	// Radius distance from the camera at which the light fades out completely
+	Radius float64
+
+	// Intensity illumination added at the center of the cone, in the same units as global illumination
+	Intensity float64
+
+	// Color of the light, each channel scales the intensity added to that channel of the lighted tint
+	Color color.NRGBA
+}
+
+// NewFlashlight creates a white flashlight with the given inner and outer cone angles (degrees)
+func NewFlashlight(innerDegrees, outerDegrees, radius, intensity float64) *Flashlight {
+	return &Flashlight{
+		InnerAngle: geom.Radians(innerDegrees),
+		OuterAngle: geom.Radians(outerDegrees),
+		Radius:     radius,
+		Intensity:  intensity,
+		Color:      color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+	}
+}
+
+// SetFlashlight sets the flashlight carried by the camera (nil to turn it off)
+func (c *Camera) SetFlashlight(flashlight *Flashlight) {
+	c.flashlight = flashlight
+}
+
+// Flashlight returns the flashlight carried by the camera, or nil if there is none
+func (c *Camera) Flashlight() *Flashlight {
+	return c.flashlight
+}
+
+// flashlightIllumination returns the illumination of each color channel of the flashlight at the given position,
+// using the height between zMin and zMax nearest to the center of the cone (such as along a wall slice)
+func (c *Camera) flashlightIllumination(x, y, zMin, zMax float64) lightRGB {
+	f := c.flashlight
+	if f == nil || f.Radius <= 0 {
+		return lightRGB{}
+	}
+
+	dX, dY := x-c.pos.X, y-c.pos.Y
+	flatDist := math.Sqrt(dX*dX + dY*dY)
+
+	// aim of the cone at the distance of the position
+	z := geom.Clamp(c.posZ+flatDist*math.Tan(c.pitchAngle), zMin, zMax)
+	dZ := z - c.posZ
+
+	dist := math.Sqrt(flatDist*flatDist + dZ*dZ)
+	if dist >= f.Radius || dist == 0 {
+		return lightRGB{}
+	}
+
+	// angle between the center of the cone and the direction to the position
+	aimX := math.Cos(c.headingAngle) * math.Cos(c.pitchAngle)
+	aimY := math.Sin(c.headingAngle) * math.Cos(c.pitchAngle)
+	aimZ := math.Sin(c.pitchAngle)
+	angle := math.Acos(geom.Clamp((dX*aimX+dY*aimY+dZ*aimZ)/dist, -1, 1))
+	if angle >= f.OuterAngle {
+		return lightRGB{}
+	}
+
+	cone := 1.0
+	if angle > f.InnerAngle {
+		cone = (f.OuterAngle - angle) / (f.OuterAngle - f.InnerAngle)
+	}
+
+	return lightFalloff(f.Intensity*cone, f.Color, dist, f.Radius)
+}
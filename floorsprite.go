@@ -0,0 +1,102 @@
+package raycaster
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// surfaces within this height of a floor sprite have it lying on them
+const floorSpriteEpsilon = 1e-6
+
+// FloorSprite represents an image lying flat on a floor with perspective, such as a puddle, rug, blood pool, or shadow
+type FloorSprite struct {
+	// Image of the sprite, transparent pixels show the floor under it
+	Image *image.RGBA
+
+	// X, Y map position of the center of the sprite
+	X, Y float64
+
+	// Z-position of the floor the sprite lies on (0.0 for the ground floor, 1.0 for the floor of the second level)
+	Z float64
+
+	// Width, Height size of the sprite in map units
+	Width, Height float64
+
+	// Angle rotation of the sprite (radians)
+	Angle float64
+
+	// rotation and bounding radius of the sprite, updated once each frame before the floor is cast
+	sin, cos, radius float64
+}
+
+// NewFloorSprite creates a flat sprite centered at the given map position on the floor at the given Z-position
+func NewFloorSprite(img *image.RGBA, x, y, z, width, height float64) *FloorSprite {
+	return &FloorSprite{Image: img, X: x, Y: y, Z: z, Width: width, Height: height}
+}
+
+// AddFloorSprite registers a flat sprite drawn on the floor, sprites added later are drawn over earlier ones
+func (c *Camera) AddFloorSprite(sprite *FloorSprite) {
+	c.floorSprites = append(c.floorSprites, sprite)
+}
+
+// RemoveFloorSprite unregisters a flat sprite drawn on the floor
+func (c *Camera) RemoveFloorSprite(sprite *FloorSprite) {
+	for i, s := range c.floorSprites {
+		if s == sprite {
+			c.floorSprites = append(c.floorSprites[:i], c.floorSprites[i+1:]...)
+			return
+		}
+	}
+}
+
+// updateFloorSprites caches the rotation and bounding radius of each flat sprite for the frame
+func (c *Camera) updateFloorSprites() {
+	for _, s := range c.floorSprites {
+		s.sin, s.cos = math.Sincos(s.Angle)
+		s.radius = math.Hypot(s.Width, s.Height) / 2
+	}
+}
+
+// texelAt returns the pixel of the sprite image at the map position, or false if it is outside of the sprite
+func (s *FloorSprite) texelAt(worldX, worldY, worldZ float64) (color.RGBA, bool) {
+	if s.Image == nil || s.Width <= 0 || s.Height <= 0 || math.Abs(worldZ-s.Z) > floorSpriteEpsilon {
+		return color.RGBA{}, false
+	}
+
+	// reject positions outside of the bounding circle before rotating
+	dX, dY := worldX-s.X, worldY-s.Y
+	if dX*dX+dY*dY > s.radius*s.radius {
+		return color.RGBA{}, false
+	}
+
+	// position relative to the center of the sprite, rotated into the sprite image axes
+	u := (dX*s.cos+dY*s.sin)/s.Width + 0.5
+	v := (-dX*s.sin+dY*s.cos)/s.Height + 0.5
+	if u < 0 || u >= 1 || v < 0 || v >= 1 {
+		return color.RGBA{}, false
+	}
+
+	bounds := s.Image.Bounds()
+	texX := bounds.Min.X + int(u*float64(bounds.Dx()))
+	texY := bounds.Min.Y + int(v*float64(bounds.Dy()))
+	return s.Image.RGBAAt(texX, texY), true
+}
+
+// castFloorSprites composites the flat sprites lying on the floor at the map position over the floor pixel
+func (c *Camera) castFloorSprites(pixel color.RGBA, worldX, worldY, worldZ float64) color.RGBA {
+	for _, s := range c.floorSprites {
+		texel, ok := s.texelAt(worldX, worldY, worldZ)
+		if !ok || texel.A == 0 {
+			continue
+		}
+
+		// source over with premultiplied alpha
+		inv := 255 - uint32(texel.A)
+		pixel.R = texel.R + uint8(uint32(pixel.R)*inv/255)
+		pixel.G = texel.G + uint8(uint32(pixel.G)*inv/255)
+		pixel.B = texel.B + uint8(uint32(pixel.B)*inv/255)
+		pixel.A = texel.A + uint8(uint32(pixel.A)*inv/255)
+	}
+	return pixel
+}
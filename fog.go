@@ -0,0 +1,76 @@
+package raycaster
+
+import (
+	"image/color"
+	"math"
+)
+
+type FogMode int
+
+const (
+	// FogNone disables distance fog
+	FogNone FogMode = iota
+	// FogLinear thickens the fog evenly from the start to the end distance
+	FogLinear
+	// FogExponential thickens the fog quickly past the start distance, easing in to the end distance
+	FogExponential
+	// FogExponentialSquared keeps the fog thin past the start distance, thickening quickly towards the end distance
+	FogExponentialSquared
+)
+
+// exponential fog curves reach this value at the end distance before being normalized to fully fogged
+const fogExpDensity = 4.0
+
+// SetFog sets distance fog blending walls, floors, ceilings and sprites toward the fog color,
+// starting at the start distance and fully fogged at the end distance (FogNone to disable)
+func (c *Camera) SetFog(mode FogMode, fogColor color.NRGBA, start, end float64) {
+	c.fogMode = mode
+	c.fogColor = fogColor
+	c.fogStart = start
+	c.fogEnd = end
+}
+
+// Fog returns the current distance fog settings
+func (c *Camera) Fog() (mode FogMode, fogColor color.NRGBA, start, end float64) {
+	return c.fogMode, c.fogColor, c.fogStart, c.fogEnd
+}
+
+// fogAmount returns the fraction (0.0 to 1.0) of the fog color that is blended in at the given distance
+func (c *Camera) fogAmount(dist float64) float64 {
+	if c.fogMode == FogNone || dist <= c.fogStart {
+		return 0
+	}
+	if dist >= c.fogEnd {
+		return 1
+	}
+
+	t := (dist - c.fogStart) / (c.fogEnd - c.fogStart)
+	switch c.fogMode {
+	case FogExponential:
+		return (1 - math.Exp(-fogExpDensity*t)) / (1 - math.Exp(-fogExpDensity))
+	case FogExponentialSquared:
+		return (1 - math.Exp(-fogExpDensity*t*t)) / (1 - math.Exp(-fogExpDensity))
+	default:
+		return t
+	}
+}
+
+// applyFog scales the tint down by the fog amount at the given distance,
+// returning the fog color to add to the tinted texture (nil if there is no fog)
+func (c *Camera) applyFog(tint *color.RGBA, dist float64) *color.RGBA {
+	fog := c.fogAmount(dist)
+	if fog <= 0 {
+		return nil
+	}
+
+	tint.R = byte(float64(tint.R) * (1 - fog))
+	tint.G = byte(float64(tint.G) * (1 - fog))
+	tint.B = byte(float64(tint.B) * (1 - fog))
+
+	return &color.RGBA{
+		R: byte(float64(c.fogColor.R) * fog),
+		G: byte(float64(c.fogColor.G) * fog),
+		B: byte(float64(c.fogColor.B) * fog),
+		A: 255,
+	}
+}
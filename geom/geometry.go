@@ -29,6 +29,13 @@ func MaxInt(x, y int) int {
 	return x
 }
 
+func MinInt(x, y int) int {
+	if x > y {
+		return y
+	}
+	return x
+}
+
 // Clamp - converted C# method MathHelper.ClampInt
 // Restricts a value to be within a specified range.
 func Clamp(value float64, min float64, max float64) float64 {
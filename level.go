@@ -3,8 +3,10 @@ package raycaster
 import (
 	"image"
 	"image/color"
+	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/harbdog/raycaster-go/geom"
 )
 
 // level --struct to represent rects and tints of vertical level slices --//
@@ -18,8 +20,59 @@ type level struct {
 	// St --current slice tint (for lighting/shading)--//
 	St []*color.RGBA
 
+	// Sa --current slice additive color (for fog), nil if none--//
+	Sa []*color.RGBA
+
 	// CurrTex --the texture to use as source
 	CurrTex []*ebiten.Image
+
+	// Zb --perpendicular distance of each slice (zbuffer for sprite casting)
+	Zb []float64
+
+	// Cf --map cell and face of each wall slice (for picking)
+	Cf []cellFace
+
+	// Decals --decal slices drawn over each wall slice
+	Decals [][]subSlice
+
+	// Parts --visible parts of each sprite or thin wall slice below its first, split by closer walls covering its middle
+	Parts [][]subSlice
+
+	// Tw --whether each slice is a thin wall or door that is seen through, drawn after the floor along with the sprites
+	Tw []bool
+
+	// Blend --how the slices blend with what is rendered behind them
+	Blend ebiten.Blend
+}
+
+// subSlice --represents a vertical slice drawn in a column with the lighting of the slice of the column--//
+type subSlice struct {
+	texture  *ebiten.Image
+	src, dst image.Rectangle
+}
+
+// clipRows returns the part of the slice drawn within the rows of the span, with its texture rows scaled to match
+func (s subSlice) clipRows(span rowSpan) (subSlice, bool) {
+	start, end := geom.MaxInt(span.start, s.dst.Min.Y), geom.MinInt(span.end, s.dst.Max.Y)
+	dstHeight, srcHeight := s.dst.Dy(), s.src.Dy()
+	if start >= end || dstHeight <= 0 || srcHeight <= 0 {
+		return subSlice{}, false
+	}
+
+	srcStart := s.src.Min.Y + (start-s.dst.Min.Y)*srcHeight/dstHeight
+	srcEnd := s.src.Min.Y + ((end-s.dst.Min.Y)*srcHeight+dstHeight-1)/dstHeight
+	if srcEnd <= srcStart {
+		srcEnd = srcStart + 1
+	}
+
+	s.src.Min.Y, s.src.Max.Y = srcStart, srcEnd
+	s.dst.Min.Y, s.dst.Max.Y = start, end
+	return s, true
+}
+
+// rowSpan --represents the rows from start up to end of a column--//
+type rowSpan struct {
+	start, end int
 }
 
 // sliceView Creates rectangle slices for each x in width.
@@ -40,6 +93,10 @@ type horLevel struct {
 	horBuffer *image.RGBA
 	// image is the ebitengine image object rendering the horBuffer during draw
 	image *ebiten.Image
+	// zBuffer is the perpendicular distance of each pixel rendered in the horBuffer (for sprite casting)
+	zBuffer []float64
+	// surfaceZ is the height of the floor or ceiling rendered at each pixel, NaN where none was (for picking)
+	surfaceZ []float64
 }
 
 func (h *horLevel) initialize(width, height int) {
@@ -47,4 +104,18 @@ func (h *horLevel) initialize(width, height int) {
 	if h.image == nil {
 		h.image = ebiten.NewImage(width, height)
 	}
+
+	if len(h.zBuffer) != width*height {
+		h.zBuffer = make([]float64, width*height)
+	}
+	for i := range h.zBuffer {
+		h.zBuffer[i] = math.MaxFloat64
+	}
+
+	if len(h.surfaceZ) != width*height {
+		h.surfaceZ = make([]float64, width*height)
+	}
+	for i := range h.surfaceZ {
+		h.surfaceZ[i] = math.NaN()
+	}
 }
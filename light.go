@@ -0,0 +1,113 @@
+package raycaster
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/harbdog/raycaster-go/geom"
+)
+
+// Light represents a point light illuminating the walls, floors, ceilings and sprites around it
+type Light struct {
+	// X, Y map position and Z-position of the light
+	X, Y, Z float64
+
+	// Radius distance from the light at which its illumination fades out completely
+	Radius float64
+
+	// Intensity illumination added at the position of the light, in the same units as global illumination
+	Intensity float64
+
+	// Color of the light, each channel scales the intensity added to that channel of the lighted tint
+	Color color.NRGBA
+}
+
+// NewLight creates a white point light at the given map position and Z-position
+func NewLight(x, y, z, radius, intensity float64) *Light {
+	return &Light{X: x, Y: y, Z: z, Radius: radius, Intensity: intensity, Color: color.NRGBA{R: 255, G: 255, B: 255, A: 255}}
+}
+
+// lightRGB is the illumination accumulated separately for each color channel
+type lightRGB struct {
+	R, G, B float64
+}
+
+// AddLight registers a point light, update its fields each tick to move or flicker the light
+func (c *Camera) AddLight(light *Light) {
+	c.lights = append(c.lights, light)
+}
+
+// RemoveLight unregisters a point light
+func (c *Camera) RemoveLight(light *Light) {
+	for i, l := range c.lights {
+		if l == light {
+			c.lights = append(c.lights[:i], c.lights[i+1:]...)
+			return
+		}
+	}
+}
+
+// illumination returns the sum of illumination of each color channel of the point lights at the given position
+func (c *Camera) illumination(x, y, z float64) lightRGB {
+	var total lightRGB
+	for _, l := range c.lights {
+		total.add(l.illuminationAt(x, y, z))
+	}
+	return total
+}
+
+// illuminationAt returns the illumination of each color channel of the light at the given position
+func (l *Light) illuminationAt(x, y, z float64) lightRGB {
+	if l.Radius <= 0 {
+		return lightRGB{}
+	}
+
+	dX, dY, dZ := x-l.X, y-l.Y, z-l.Z
+	dist := math.Sqrt(dX*dX + dY*dY + dZ*dZ)
+	return lightFalloff(l.Intensity, l.Color, dist, l.Radius)
+}
+
+// lightFalloff returns the illumination of each color channel of a light of the given intensity and color
+// at the given distance from it
+func lightFalloff(intensity float64, lightColor color.NRGBA, dist, radius float64) lightRGB {
+	if dist >= radius {
+		return lightRGB{}
+	}
+
+	// smooth falloff reaching nothing at the radius of the light
+	falloff := 1 - dist/radius
+	intensity *= falloff * falloff
+	return lightRGB{
+		R: intensity * float64(lightColor.R) / 255,
+		G: intensity * float64(lightColor.G) / 255,
+		B: intensity * float64(lightColor.B) / 255,
+	}
+}
+
+// add accumulates the illumination of each color channel
+func (rgb *lightRGB) add(other lightRGB) {
+	rgb.R += other.R
+	rgb.G += other.G
+	rgb.B += other.B
+}
+
+// lightTint returns the tint of a surface lighted by the given illumination, clamped to the min/max light RGB,
+// with the colored point lights added on top of it so they are not limited by the max light RGB
+func (c *Camera) lightTint(illumination float64, lights lightRGB) *color.RGBA {
+	tint := &color.RGBA{255, 255, 255, 255}
+	tint.R = byte(geom.ClampInt(geom.ClampInt(int(float64(tint.R)+illumination), int(c.minLightRGB.R), int(c.maxLightRGB.R))+int(lights.R), 0, 255))
+	tint.G = byte(geom.ClampInt(geom.ClampInt(int(float64(tint.G)+illumination), int(c.minLightRGB.G), int(c.maxLightRGB.G))+int(lights.G), 0, 255))
+	tint.B = byte(geom.ClampInt(geom.ClampInt(int(float64(tint.B)+illumination), int(c.minLightRGB.B), int(c.maxLightRGB.B))+int(lights.B), 0, 255))
+	return tint
+}
+
+// cellIllumination returns the illumination of the map cell from the LightMap, or the global illumination
+func (c *Camera) cellIllumination(x, y, levelNum int) float64 {
+	lightMap, ok := c.mapObj.(LightMap)
+	if !ok || !c.inBounds(x, y) {
+		return c.globalIllumination
+	}
+
+	levelNum = geom.ClampInt(levelNum, 0, c.mapObj.NumLevels()-1)
+	return lightMap.LightAt(x, y, levelNum)
+}
@@ -0,0 +1,258 @@
+package raycaster
+
+import (
+	"math"
+)
+
+// number of faces of a wall block within its map cell
+const numWallFaces = 4
+
+// distance off of a surface that light is sampled at, so the surface itself does not block it
+const lightmapEpsilon = 1e-3
+
+// BakedLightmap stores the illumination of static lights baked for each wall face and floor texel of a map,
+// so light does not bleed through the walls between the lights and each surface
+type BakedLightmap struct {
+	width, height, numLevels int
+
+	// number of light values stored along each axis of a map cell
+	texels int
+
+	// illumination of each wall face texel by level, map cell, and face
+	walls []lightRGB
+
+	// illumination of each floor texel by the height of the floor (0 to NumLevels)
+	floors []lightRGB
+
+	// illumination of each ceiling texel by the level it is the top of
+	ceilings []lightRGB
+}
+
+// BakeLightmap traces shadow rays from each static light to the wall faces, floors and ceilings of the map.
+// texelsPerCell sets how many light values are stored along each axis of a map cell (minimum of 1).
+// Walls block light within their height, thin walls do not block light. Doors are not known to the map,
+// so the wall present in a door cell blocks light unless the map also reports it as a thin wall.
+func BakeLightmap(mapObj Map, lights []*Light, texelsPerCell int) *BakedLightmap {
+	if texelsPerCell < 1 {
+		texelsPerCell = 1
+	}
+
+	firstLevel := mapObj.Level(0)
+	lm := &BakedLightmap{
+		width:     len(firstLevel),
+		height:    len(firstLevel[0]),
+		numLevels: mapObj.NumLevels(),
+		texels:    texelsPerCell,
+	}
+
+	numCells := lm.width * lm.height
+	lm.walls = make([]lightRGB, lm.numLevels*numCells*numWallFaces*lm.texels)
+	lm.floors = make([]lightRGB, (lm.numLevels+1)*numCells*lm.texels*lm.texels)
+	lm.ceilings = make([]lightRGB, lm.numLevels*numCells*lm.texels*lm.texels)
+
+	t := float64(lm.texels)
+	for levelNum := 0; levelNum <= lm.numLevels; levelNum++ {
+		var grid [][]int
+		if levelNum < lm.numLevels {
+			grid = mapObj.Level(levelNum)
+		}
+
+		for x := 0; x < lm.width; x++ {
+			for y := 0; y < lm.height; y++ {
+				// floors lighted from above, ceilings from below
+				for i := 0; i < lm.texels; i++ {
+					for j := 0; j < lm.texels; j++ {
+						pX, pY := float64(x)+(float64(i)+0.5)/t, float64(y)+(float64(j)+0.5)/t
+						texel := lm.texelIndex(levelNum, x, y, i, j)
+
+						floorZ := float64(levelNum) + lightmapEpsilon
+						lm.floors[texel] = bakeIllumination(mapObj, lights, pX, pY, floorZ, 0, 0, 1)
+
+						if levelNum < lm.numLevels {
+							ceilingZ := float64(levelNum+1) - lightmapEpsilon
+							lm.ceilings[texel] = bakeIllumination(mapObj, lights, pX, pY, ceilingZ, 0, 0, -1)
+						}
+					}
+				}
+
+				if grid == nil || grid[x][y] <= 0 {
+					continue
+				}
+
+				// wall faces lighted from the middle of the wall height
+				bottom, top := 0.0, 1.0
+				if heights, ok := mapObj.(CellHeightMap); ok {
+					bottom, top = heights.CellHeight(levelNum, x, y)
+				}
+				pZ := float64(levelNum) + (bottom+top)/2
+
+				for i := 0; i < lm.texels; i++ {
+					along := (float64(i) + 0.5) / t
+					lm.walls[lm.wallIndex(levelNum, x, y, WallFaceWest, i)] = bakeIllumination(mapObj, lights,
+						float64(x)-lightmapEpsilon, float64(y)+along, pZ, -1, 0, 0)
+					lm.walls[lm.wallIndex(levelNum, x, y, WallFaceEast, i)] = bakeIllumination(mapObj, lights,
+						float64(x+1)+lightmapEpsilon, float64(y)+along, pZ, 1, 0, 0)
+					lm.walls[lm.wallIndex(levelNum, x, y, WallFaceNorth, i)] = bakeIllumination(mapObj, lights,
+						float64(x)+along, float64(y)-lightmapEpsilon, pZ, 0, -1, 0)
+					lm.walls[lm.wallIndex(levelNum, x, y, WallFaceSouth, i)] = bakeIllumination(mapObj, lights,
+						float64(x)+along, float64(y+1)+lightmapEpsilon, pZ, 0, 1, 0)
+				}
+			}
+		}
+	}
+
+	return lm
+}
+
+// SetLightmap sets the baked illumination of static lights, added to the illumination of registered lights (nil to remove it)
+func (c *Camera) SetLightmap(lightmap *BakedLightmap) {
+	c.lightmap = lightmap
+}
+
+// bakeIllumination returns the illumination of the lights that reach a surface point facing the given normal
+func bakeIllumination(mapObj Map, lights []*Light, pX, pY, pZ, nX, nY, nZ float64) lightRGB {
+	var total lightRGB
+	for _, l := range lights {
+		if (l.X-pX)*nX+(l.Y-pY)*nY+(l.Z-pZ)*nZ <= 0 {
+			// surface is facing away from the light
+			continue
+		}
+
+		illumination := l.illuminationAt(pX, pY, pZ)
+		if illumination == (lightRGB{}) || isLightBlocked(mapObj, l.X, l.Y, l.Z, pX, pY, pZ) {
+			continue
+		}
+		total.add(illumination)
+	}
+	return total
+}
+
+// isLightBlocked returns true if a wall is between the two points, stepping through the map cells with DDA
+// (the map cells of the points themselves do not block the light)
+func isLightBlocked(mapObj Map, fromX, fromY, fromZ, toX, toY, toZ float64) bool {
+	dirX, dirY := toX-fromX, toY-fromY
+	mapX, mapY := int(math.Floor(fromX)), int(math.Floor(fromY))
+	endX, endY := int(math.Floor(toX)), int(math.Floor(toY))
+
+	//length along the segment (0.0 to 1.0) from one x or y-side to next x or y-side
+	deltaDistX := math.Abs(1 / dirX)
+	deltaDistY := math.Abs(1 / dirY)
+
+	stepX, stepY := 1, 1
+	sideDistX := (float64(mapX) + 1.0 - fromX) * deltaDistX
+	sideDistY := (float64(mapY) + 1.0 - fromY) * deltaDistY
+	if dirX < 0 {
+		stepX = -1
+		sideDistX = (fromX - float64(mapX)) * deltaDistX
+	}
+	if dirY < 0 {
+		stepY = -1
+		sideDistY = (fromY - float64(mapY)) * deltaDistY
+	}
+
+	heights, _ := mapObj.(CellHeightMap)
+	thinWalls, _ := mapObj.(ThinWallMap)
+	firstLevel := mapObj.Level(0)
+	width, height, numLevels := len(firstLevel), len(firstLevel[0]), mapObj.NumLevels()
+
+	for mapX != endX || mapY != endY {
+		var enter float64
+		if sideDistX < sideDistY {
+			enter = sideDistX
+			sideDistX += deltaDistX
+			mapX += stepX
+		} else {
+			enter = sideDistY
+			sideDistY += deltaDistY
+			mapY += stepY
+		}
+
+		if enter >= 1 || (mapX == endX && mapY == endY) {
+			break
+		}
+		if mapX < 0 || mapY < 0 || mapX >= width || mapY >= height {
+			continue
+		}
+
+		// range of heights the segment passes through the map cell at
+		exit := math.Min(math.Min(sideDistX, sideDistY), 1)
+		zIn, zOut := fromZ+enter*(toZ-fromZ), fromZ+exit*(toZ-fromZ)
+		zMin, zMax := math.Min(zIn, zOut), math.Max(zIn, zOut)
+
+		for levelNum := int(math.Floor(zMin)); levelNum <= int(math.Floor(zMax)); levelNum++ {
+			if levelNum < 0 || levelNum >= numLevels || mapObj.Level(levelNum)[mapX][mapY] <= 0 {
+				continue
+			}
+			if thinWalls != nil {
+				if orientation, _ := thinWalls.ThinWallAt(mapX, mapY, levelNum); orientation != ThinWallNone {
+					continue
+				}
+			}
+
+			bottom, top := 0.0, 1.0
+			if heights != nil {
+				bottom, top = heights.CellHeight(levelNum, mapX, mapY)
+			}
+			if zMax >= float64(levelNum)+bottom && zMin <= float64(levelNum)+top {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// texelIndex returns the index of a floor or ceiling texel
+func (lm *BakedLightmap) texelIndex(levelNum, x, y, i, j int) int {
+	return (((levelNum*lm.width+x)*lm.height+y)*lm.texels+i)*lm.texels + j
+}
+
+// wallIndex returns the index of a wall face texel
+func (lm *BakedLightmap) wallIndex(levelNum, x, y int, face WallFace, i int) int {
+	return (((levelNum*lm.width+x)*lm.height+y)*numWallFaces+int(face))*lm.texels + i
+}
+
+// wallAt returns the baked illumination of a wall face at wallX along it
+func (lm *BakedLightmap) wallAt(levelNum, mapX, mapY int, face WallFace, wallX float64) lightRGB {
+	if levelNum < 0 || levelNum >= lm.numLevels || mapX < 0 || mapY < 0 || mapX >= lm.width || mapY >= lm.height {
+		return lightRGB{}
+	}
+	i := lm.texelAt(wallX)
+	return lm.walls[lm.wallIndex(levelNum, mapX, mapY, face, i)]
+}
+
+// floorAt returns the baked illumination of the floor at the given height (level number) and map position
+func (lm *BakedLightmap) floorAt(worldX, worldY float64, levelNum int) lightRGB {
+	if levelNum < 0 || levelNum > lm.numLevels {
+		return lightRGB{}
+	}
+	return lm.horizontalAt(lm.floors, worldX, worldY, levelNum)
+}
+
+// ceilingAt returns the baked illumination of the ceiling at the top of the level at the given map position
+func (lm *BakedLightmap) ceilingAt(worldX, worldY float64, levelNum int) lightRGB {
+	if levelNum < 0 || levelNum >= lm.numLevels {
+		return lightRGB{}
+	}
+	return lm.horizontalAt(lm.ceilings, worldX, worldY, levelNum)
+}
+
+func (lm *BakedLightmap) horizontalAt(texels []lightRGB, worldX, worldY float64, levelNum int) lightRGB {
+	mapX, mapY := int(math.Floor(worldX)), int(math.Floor(worldY))
+	if mapX < 0 || mapY < 0 || mapX >= lm.width || mapY >= lm.height {
+		return lightRGB{}
+	}
+	i, j := lm.texelAt(worldX-float64(mapX)), lm.texelAt(worldY-float64(mapY))
+	return texels[lm.texelIndex(levelNum, mapX, mapY, i, j)]
+}
+
+// texelAt returns the texel for a position (0.0 to 1.0) within a map cell
+func (lm *BakedLightmap) texelAt(pos float64) int {
+	i := int(pos * float64(lm.texels))
+	if i < 0 {
+		return 0
+	} else if i >= lm.texels {
+		return lm.texels - 1
+	}
+	return i
+}
@@ -7,3 +7,37 @@ type Map interface {
 	// NumLevels returns the number of vertical levels (minimum of 1)
 	NumLevels() int
 }
+
+// ThinWallMap is an optional extension of Map for thin walls rendered inside of a map cell (fences, bars, windows)
+type ThinWallMap interface {
+	// ThinWallAt returns the orientation and offset (0.0 to 1.0 within the cell, 0.5 for the midline)
+	// of the thin wall at the given map coordinates and level number, or ThinWallNone for a regular wall
+	ThinWallAt(x, y, levelNum int) (orientation ThinWallOrientation, offset float64)
+}
+
+type ThinWallOrientation int
+
+const (
+	// ThinWallNone indicates a regular wall filling the whole map cell
+	ThinWallNone ThinWallOrientation = iota
+	// ThinWallX indicates a thin wall along the Y-axis, placed at an X-axis offset within the map cell
+	ThinWallX
+	// ThinWallY indicates a thin wall along the X-axis, placed at a Y-axis offset within the map cell
+	ThinWallY
+)
+
+// CellHeightMap is an optional extension of Map for walls that do not fill the whole height of their level
+// (low walls, crates, counters, tall pillars)
+type CellHeightMap interface {
+	// CellHeight returns the bottom and top of the wall at the given level number and map coordinates,
+	// relative to the bottom of the level (0.0 and 1.0 for a wall filling the whole level)
+	CellHeight(levelNum, x, y int) (bottom, top float64)
+}
+
+// LightMap is an optional extension of Map for light levels that differ by map cell, such as a dark corridor
+// next to a bright hall
+type LightMap interface {
+	// LightAt returns the illumination at the given map coordinates and level number,
+	// used instead of the global illumination for the walls, floors, ceilings and sprites in that map cell
+	LightAt(x, y, levelNum int) float64
+}
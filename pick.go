@@ -0,0 +1,187 @@
+package raycaster
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/harbdog/raycaster-go/geom3d"
+)
+
+// PickType is what was found under a screen pixel
+type PickType int
+
+const (
+	// PickNone nothing but the sky is rendered at the pixel
+	PickNone PickType = iota
+	// PickWall a wall face is rendered at the pixel
+	PickWall
+	// PickFloor a floor, or the top of a wall lower than its level, is rendered at the pixel
+	PickFloor
+	// PickCeiling a ceiling is rendered at the pixel
+	PickCeiling
+	// PickSprite a visible pixel of a sprite texture is rendered at the pixel
+	PickSprite
+)
+
+// Pick is the front-most wall, floor, ceiling, or sprite rendered at a screen pixel
+type Pick struct {
+	// Type of what was picked (PickNone if only the sky is rendered at the pixel)
+	Type PickType
+
+	// X, Y map cell of the wall, floor, ceiling, or at the point of the sprite that was picked
+	X, Y int
+
+	// LevelNum level of the wall, the level a floor is the bottom of, or the level a ceiling is the top of
+	LevelNum int
+
+	// Face of the wall that was picked
+	Face WallFace
+
+	// Sprite that was picked
+	Sprite Sprite
+
+	// Point 3-Dimensional position in the map that was picked
+	Point *geom3d.Vector3
+
+	// Distance from the camera to the point
+	Distance float64
+}
+
+// PickAt returns the front-most wall cell and face, floor or ceiling cell, or sprite rendered at the screen pixel
+// as of the last Update, transparent pixels of sprite, thin wall and door textures are passed through.
+// Sprite texture pixels are read back from the GPU, so it should only be called while the game is running.
+func (c *Camera) PickAt(screenX, screenY int) *Pick {
+	pick := &Pick{Type: PickNone}
+	if screenX < 0 || screenY < 0 || screenX >= c.w || screenY >= c.h {
+		return pick
+	}
+
+	// sprites, thin walls and doors are drawn over everything else, closest last
+	var front *level
+	frontSprite := -1
+	for i, spriteLvl := range c.spriteLvls {
+		if spriteLvl != nil && isSlicePixelOpaque(spriteLvl, screenX, screenY) && (front == nil || spriteLvl.Zb[screenX] < front.Zb[screenX]) {
+			front, frontSprite = spriteLvl, i
+		}
+	}
+	for _, layers := range c.levels {
+		for _, lvl := range layers {
+			if lvl.Tw[screenX] && isSlicePixelOpaque(lvl, screenX, screenY) && (front == nil || lvl.Zb[screenX] < front.Zb[screenX]) {
+				front, frontSprite = lvl, -1
+			}
+		}
+	}
+
+	if front != nil {
+		if frontSprite >= 0 {
+			pick.Type = PickSprite
+			pick.Sprite = c.sprites[c.spriteOrder[frontSprite]]
+		} else {
+			cf := front.Cf[screenX]
+			pick.Type = PickWall
+			pick.X, pick.Y, pick.LevelNum = cf.cell.x, cf.cell.y, cf.cell.levelNum
+			pick.Face = cf.face
+		}
+		pick.Point = c.screenPoint(screenX, screenY, front.Zb[screenX])
+	}
+
+	// floors and ceilings are drawn over the walls they are in front of
+	if pick.Type == PickNone {
+		if surfaceZ := c.floorLvl.surfaceZ[screenY*c.w+screenX]; !math.IsNaN(surfaceZ) {
+			rowDiv := 2.0*float64(screenY-c.pitch) - float64(c.h)
+			pick.Point = c.screenPoint(screenX, screenY, c.surfaceDist(surfaceZ, rowDiv))
+			pick.Point.Z = surfaceZ
+
+			if surfaceZ > c.posZ {
+				pick.Type = PickCeiling
+				pick.LevelNum = int(math.Ceil(surfaceZ)) - 1
+			} else {
+				pick.Type = PickFloor
+				pick.LevelNum = int(math.Floor(surfaceZ))
+			}
+		}
+	}
+
+	// the closest wall slice covering the pixel is drawn last (thin walls and doors were already picked above)
+	if pick.Type == PickNone {
+		var wallLvl *level
+		for _, layers := range c.levels {
+			for _, lvl := range layers {
+				if lvl.CurrTex[screenX] == nil || lvl.Tw[screenX] || screenY < lvl.Sv[screenX].Min.Y || screenY >= lvl.Sv[screenX].Max.Y {
+					continue
+				}
+				if wallLvl == nil || lvl.Zb[screenX] < wallLvl.Zb[screenX] {
+					wallLvl = lvl
+				}
+			}
+		}
+
+		if wallLvl != nil {
+			cf := wallLvl.Cf[screenX]
+			pick.Type = PickWall
+			pick.X, pick.Y, pick.LevelNum = cf.cell.x, cf.cell.y, cf.cell.levelNum
+			pick.Face = cf.face
+			pick.Point = c.screenPoint(screenX, screenY, wallLvl.Zb[screenX])
+		}
+	}
+
+	if pick.Point != nil {
+		if pick.Type != PickWall {
+			pick.X, pick.Y = int(math.Floor(pick.Point.X)), int(math.Floor(pick.Point.Y))
+		}
+		if pick.Type == PickSprite {
+			pick.LevelNum = int(math.Floor(pick.Point.Z))
+		}
+
+		line := geom3d.Line3d{X1: c.pos.X, Y1: c.pos.Y, Z1: c.posZ, X2: pick.Point.X, Y2: pick.Point.Y, Z2: pick.Point.Z}
+		pick.Distance = line.Distance()
+	}
+
+	return pick
+}
+
+// isSlicePixelOpaque returns true if the sprite stripe or thin wall slice rendered in the column,
+// or any of the parts it is split into, has a visible pixel at the screen row
+func isSlicePixelOpaque(lvl *level, x, y int) bool {
+	texture := lvl.CurrTex[x]
+	if texture == nil {
+		return false
+	}
+	if tint := lvl.St[x]; tint != nil && tint.A == 0 {
+		return false
+	}
+
+	if isSliceTexelOpaque(texture, lvl.Sv[x], lvl.Cts[x], y) {
+		return true
+	}
+	for i := range lvl.Parts[x] {
+		p := &lvl.Parts[x][i]
+		if isSliceTexelOpaque(p.texture, &p.dst, &p.src, y) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSliceTexelOpaque returns true if the texture slice drawn to the screen slice has a visible pixel at the screen row
+func isSliceTexelOpaque(texture *ebiten.Image, sv, cts *image.Rectangle, y int) bool {
+	if texture == nil || sv == nil || cts == nil || y < sv.Min.Y || y >= sv.Max.Y {
+		return false
+	}
+
+	// texture row scaled the same as the slice is drawn
+	texY := cts.Min.Y + (y-sv.Min.Y)*cts.Dy()/sv.Dy()
+	_, _, _, a := texture.At(cts.Min.X, texY).RGBA()
+	return a > 0
+}
+
+// screenPoint returns the map position seen at the screen pixel at the given perpendicular distance
+func (c *Camera) screenPoint(x, y int, dist float64) *geom3d.Vector3 {
+	cameraX := 2.0*float64(x)/float64(c.w) - 1.0 //x-coordinate in camera space
+	return &geom3d.Vector3{
+		X: c.pos.X + dist*(c.dir.X+c.plane.X*cameraX),
+		Y: c.pos.Y + dist*(c.dir.Y+c.plane.Y*cameraX),
+		Z: c.posZ + float64(c.h/2+c.pitch-y)*dist/float64(c.h),
+	}
+}
@@ -0,0 +1,142 @@
+package raycaster
+
+import (
+	"math"
+)
+
+// PushWall represents a wall block sliding through the map grid, such as a secret push wall
+type PushWall struct {
+	// X, Y map coordinates and level number of the map cell the wall started moving from
+	X, Y, LevelNum int
+
+	// DirX, DirY direction the wall is moving in along a single axis (-1, 0, or 1)
+	DirX, DirY int
+
+	// Offset distance in map cells that the wall has moved from its starting map cell
+	Offset float64
+}
+
+// NewPushWall creates a push wall at the given map coordinates and level number moving in the given direction
+func NewPushWall(x, y, levelNum, dirX, dirY int) *PushWall {
+	return &PushWall{X: x, Y: y, LevelNum: levelNum, DirX: dirX, DirY: dirY}
+}
+
+// AddPushWall registers a push wall to be rendered moving through the map grid.
+// The starting map cell keeps its wall present to provide the texture, but is otherwise rendered as empty
+// until the push wall is removed.
+func (c *Camera) AddPushWall(pushWall *PushWall) {
+	c.pushWalls = append(c.pushWalls, pushWall)
+}
+
+// RemovePushWall unregisters a push wall, such as after it has finished moving and its map cells are updated
+func (c *Camera) RemovePushWall(pushWall *PushWall) {
+	for i, p := range c.pushWalls {
+		if p == pushWall {
+			c.pushWalls = append(c.pushWalls[:i], c.pushWalls[i+1:]...)
+			return
+		}
+	}
+}
+
+// isPushWallOrigin returns true if the map cell is the starting map cell of a registered push wall
+func (c *Camera) isPushWallOrigin(mapX, mapY, levelNum int) bool {
+	for _, p := range c.pushWalls {
+		if p.X == mapX && p.Y == mapY && p.LevelNum == levelNum {
+			return true
+		}
+	}
+	return false
+}
+
+// castPushWalls finds the nearest push wall that the ray hits within the given map cell
+func (c *Camera) castPushWalls(rayPosX, rayPosY, rayDirX, rayDirY float64, mapX, mapY, levelNum int) (*PushWall, float64, float64, int) {
+	var hitPushWall *PushWall
+	var hitDist, hitWallX float64
+	var hitSide int
+
+	for _, p := range c.pushWalls {
+		if p.LevelNum != levelNum {
+			continue
+		}
+
+		perpWallDist, wallX, side, hit := p.cast(rayPosX, rayPosY, rayDirX, rayDirY, mapX, mapY)
+		if hit && (hitPushWall == nil || perpWallDist < hitDist) {
+			hitPushWall, hitDist, hitWallX, hitSide = p, perpWallDist, wallX, side
+		}
+	}
+
+	return hitPushWall, hitDist, hitWallX, hitSide
+}
+
+// position returns the map position of the lower corner of the moving wall block
+func (p *PushWall) position() (float64, float64) {
+	return float64(p.X) + float64(p.DirX)*p.Offset, float64(p.Y) + float64(p.DirY)*p.Offset
+}
+
+// cast finds the perpendicular distance, wallX, and side where the ray enters the moving wall block
+// if the entry point is within the given map cell
+func (p *PushWall) cast(rayPosX, rayPosY, rayDirX, rayDirY float64, mapX, mapY int) (float64, float64, int, bool) {
+	boxX, boxY := p.position()
+	if boxX >= float64(mapX+1) || boxX+1 <= float64(mapX) || boxY >= float64(mapY+1) || boxY+1 <= float64(mapY) {
+		// block is not moving through this map cell
+		return 0, 0, 0, false
+	}
+
+	// ray to axis-aligned box intersection using the slab method
+	tMinX, tMaxX, okX := castSlab(rayPosX, rayDirX, boxX, boxX+1)
+	tMinY, tMaxY, okY := castSlab(rayPosY, rayDirY, boxY, boxY+1)
+	if !okX || !okY {
+		return 0, 0, 0, false
+	}
+
+	perpWallDist := math.Max(tMinX, tMinY)
+	if perpWallDist <= 0 || perpWallDist > math.Min(tMaxX, tMaxY) {
+		return 0, 0, 0, false
+	}
+
+	// the hit is snapped to the face it enters on, so a face on the edge of a map cell does not round out of it
+	hitX, hitY := rayPosX+perpWallDist*rayDirX, rayPosY+perpWallDist*rayDirY
+	side := 0
+	if tMinX >= tMinY {
+		hitX = boxX
+		if rayDirX < 0 {
+			hitX = boxX + 1
+		}
+	} else {
+		side = 1
+		hitY = boxY
+		if rayDirY < 0 {
+			hitY = boxY + 1
+		}
+	}
+
+	if !isInCell(hitX, mapX) || !isInCell(hitY, mapY) {
+		// ray enters the block within another map cell
+		return 0, 0, 0, false
+	}
+
+	// wallX is measured along the face of the block so the texture moves with it
+	wallX := hitX - boxX
+	if side == 0 {
+		wallX = hitY - boxY
+	}
+	wallX = math.Max(0, math.Min(wallX, math.Nextafter(1, 0)))
+
+	return perpWallDist, wallX, side, true
+}
+
+// castSlab finds the ray distances entering and exiting the slab between min and max along one axis
+func castSlab(rayPos, rayDir, min, max float64) (float64, float64, bool) {
+	if rayDir == 0 {
+		if rayPos < min || rayPos > max {
+			return 0, 0, false
+		}
+		return math.Inf(-1), math.Inf(1), true
+	}
+
+	t1, t2 := (min-rayPos)/rayDir, (max-rayPos)/rayDir
+	if t1 > t2 {
+		t1, t2 = t2, t1
+	}
+	return t1, t2, true
+}
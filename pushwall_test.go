@@ -0,0 +1,106 @@
+package raycaster
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPushWallCast(t *testing.T) {
+	tests := []struct {
+		name             string
+		pushWall         PushWall
+		rayPosX, rayPosY float64
+		rayDirX, rayDirY float64
+		mapX, mapY       int
+		hit              bool
+		perpWallDist     float64
+		wallX            float64
+		side             int
+	}{
+		{
+			name:     "not moved, entering west face",
+			pushWall: PushWall{X: 3, Y: 2, DirX: 1},
+			rayPosX:  0.5, rayPosY: 2.25, rayDirX: 1, rayDirY: 0,
+			mapX: 3, mapY: 2,
+			hit: true, perpWallDist: 2.5, wallX: 0.25, side: 0,
+		},
+		{
+			name:     "moved one cell, entering east face",
+			pushWall: PushWall{X: 3, Y: 2, DirX: -1, Offset: 1},
+			rayPosX:  5.5, rayPosY: 2.75, rayDirX: -1, rayDirY: 0,
+			mapX: 2, mapY: 2,
+			hit: true, perpWallDist: 2.5, wallX: 0.75, side: 0,
+		},
+		{
+			name:     "moving between cells, entering north face",
+			pushWall: PushWall{X: 3, Y: 2, DirX: 1, Offset: 0.5},
+			rayPosX:  4.25, rayPosY: 0.5, rayDirX: 0, rayDirY: 1,
+			mapX: 4, mapY: 2,
+			hit: true, perpWallDist: 1.5, wallX: 0.75, side: 1,
+		},
+		{
+			name:     "entering within another map cell",
+			pushWall: PushWall{X: 3, Y: 2, DirX: 1, Offset: 0.5},
+			rayPosX:  3.75, rayPosY: 0.5, rayDirX: 0, rayDirY: 1,
+			mapX: 4, mapY: 2,
+			hit: false,
+		},
+		{
+			name:     "not moving through the map cell",
+			pushWall: PushWall{X: 3, Y: 2, DirX: 1, Offset: 0.5},
+			rayPosX:  0.5, rayPosY: 2.5, rayDirX: 1, rayDirY: 0,
+			mapX: 5, mapY: 2,
+			hit: false,
+		},
+		{
+			name:     "behind the ray",
+			pushWall: PushWall{X: 3, Y: 2, DirX: 1},
+			rayPosX:  5.5, rayPosY: 2.5, rayDirX: 1, rayDirY: 0,
+			mapX: 3, mapY: 2,
+			hit: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perpWallDist, wallX, side, hit := tt.pushWall.cast(tt.rayPosX, tt.rayPosY, tt.rayDirX, tt.rayDirY, tt.mapX, tt.mapY)
+			if hit != tt.hit {
+				t.Fatalf("hit = %v, want %v", hit, tt.hit)
+			}
+			if !hit {
+				return
+			}
+			if math.Abs(perpWallDist-tt.perpWallDist) > 1e-9 || math.Abs(wallX-tt.wallX) > 1e-9 || side != tt.side {
+				t.Errorf("got (%v, %v, %v), want (%v, %v, %v)", perpWallDist, wallX, side, tt.perpWallDist, tt.wallX, tt.side)
+			}
+		})
+	}
+}
+
+func TestPushWallCastFaceOnCellEdge(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, offset := range []float64{0, 1, 2} {
+		p := &PushWall{X: 5, Y: 5, DirX: -1, Offset: offset}
+		boxX, boxY := p.position()
+		mapX, mapY := int(boxX), int(boxY)
+
+		for i := 0; i < 10000; i++ {
+			// rays from either side aimed at a point on the west or east face of the block
+			faceX := boxX
+			rayPosX := boxX - 1 - 4*rng.Float64()
+			if i%2 == 1 {
+				faceX = boxX + 1
+				rayPosX = boxX + 2 + 4*rng.Float64()
+			}
+			rayPosY := boxY - 3 + 7*rng.Float64()
+			faceY := boxY + 0.01 + 0.98*rng.Float64()
+
+			rayDirX, rayDirY := faceX-rayPosX, faceY-rayPosY
+			if _, _, side, hit := p.cast(rayPosX, rayPosY, rayDirX, rayDirY, mapX, mapY); !hit || side != 0 {
+				t.Fatalf("offset %v: ray from (%v, %v) to (%v, %v) missed the face", offset, rayPosX, rayPosY, faceX, faceY)
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"image/color"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
 )
 
 // Draw the raycasted camera view to the screen.
@@ -15,15 +16,24 @@ func (c *Camera) Draw(screen *ebiten.Image) {
 
 	floorRect := image.Rect(0, int(float64(c.h)*0.5)+c.pitch,
 		c.w, c.h)
-	drawTexture(screen, c.floor, &floorRect, &texRect, lightingRGBA)
+	drawTexture(screen, c.floor, &floorRect, &texRect, lightingRGBA, nil, ebiten.BlendSourceOver)
 
-	skyRect := image.Rect(0, 0, c.w, int(float64(c.h)*0.5)+c.pitch)
-	drawTexture(screen, c.sky, &skyRect, &texRect, lightingRGBA)
+	c.drawSky(screen, lightingRGBA)
 
-	//--draw walls--//
+	//--draw walls, farthest first since walls can be seen past closer walls on other levels--//
 	for x := 0; x < c.w; x++ {
-		for i := cap(c.levels) - 1; i >= 0; i-- {
-			drawTexture(screen, c.levels[i].CurrTex[x], c.levels[i].Sv[x], c.levels[i].Cts[x], c.levels[i].St[x])
+		c.wallOrder = c.wallOrder[:0]
+		for i := len(c.levels) - 1; i >= 0; i-- {
+			for _, lvl := range c.levels[i] {
+				if lvl.CurrTex[x] != nil && !lvl.Tw[x] {
+					c.wallOrder = append(c.wallOrder, lvl)
+				}
+			}
+		}
+		sortLevelsByDepth(c.wallOrder, x)
+
+		for _, lvl := range c.wallOrder {
+			drawSlice(screen, lvl, x)
 		}
 	}
 
@@ -36,23 +46,59 @@ func (c *Camera) Draw(screen *ebiten.Image) {
 		screen.DrawImage(c.floorLvl.image, op)
 	}
 
-	// draw sprites
+	//--draw sprites, thin walls and doors, farthest first since they can be seen through--//
 	for x := 0; x < c.w; x++ {
+		c.wallOrder = c.wallOrder[:0]
+		for i := len(c.levels) - 1; i >= 0; i-- {
+			for _, lvl := range c.levels[i] {
+				if lvl.CurrTex[x] != nil && lvl.Tw[x] {
+					c.wallOrder = append(c.wallOrder, lvl)
+				}
+			}
+		}
 		for i := 0; i < cap(c.spriteLvls); i++ {
 			spriteLvl := c.spriteLvls[i]
-			if spriteLvl == nil {
-				continue
+			if spriteLvl != nil && spriteLvl.CurrTex[x] != nil {
+				c.wallOrder = append(c.wallOrder, spriteLvl)
 			}
+		}
+		sortLevelsByDepth(c.wallOrder, x)
 
-			texture := spriteLvl.CurrTex[x]
-			if texture != nil {
-				drawTexture(screen, texture, spriteLvl.Sv[x], spriteLvl.Cts[x], spriteLvl.St[x])
-			}
+		for _, lvl := range c.wallOrder {
+			drawSlice(screen, lvl, x)
+		}
+	}
+}
+
+// drawSlice draws the slice of the column with the parts it is split into and the decals over it,
+// all using the same lighting
+func drawSlice(screen *ebiten.Image, lvl *level, x int) {
+	drawTexture(screen, lvl.CurrTex[x], lvl.Sv[x], lvl.Cts[x], lvl.St[x], lvl.Sa[x], lvl.Blend)
+
+	if lvl.Parts != nil {
+		for i := range lvl.Parts[x] {
+			p := &lvl.Parts[x][i]
+			drawTexture(screen, p.texture, &p.dst, &p.src, lvl.St[x], lvl.Sa[x], lvl.Blend)
+		}
+	}
+	if lvl.Decals != nil {
+		for i := range lvl.Decals[x] {
+			d := &lvl.Decals[x][i]
+			drawTexture(screen, d.texture, &d.dst, &d.src, lvl.St[x], lvl.Sa[x], lvl.Blend)
 		}
 	}
 }
 
-func drawTexture(screen *ebiten.Image, texture *ebiten.Image, destinationRectangle *image.Rectangle, sourceRectangle *image.Rectangle, color *color.RGBA) {
+// sortLevelsByDepth sorts level slices of the column from farthest to closest (insertion sort, stable for equal depth)
+func sortLevelsByDepth(levels []*level, x int) {
+	for i := 1; i < len(levels); i++ {
+		for j := i; j > 0 && levels[j-1].Zb[x] < levels[j].Zb[x]; j-- {
+			levels[j-1], levels[j] = levels[j], levels[j-1]
+		}
+	}
+}
+
+func drawTexture(screen *ebiten.Image, texture *ebiten.Image, destinationRectangle *image.Rectangle, sourceRectangle *image.Rectangle, color *color.RGBA, add *color.RGBA, blend ebiten.Blend) {
 	if texture == nil || destinationRectangle == nil || sourceRectangle == nil {
 		return
 	}
@@ -67,17 +113,40 @@ func drawTexture(screen *ebiten.Image, texture *ebiten.Image, destinationRectang
 		scaleY = float64(dSize.Y) / float64(sSize.Y)
 	}
 
+	destTexture := texture.SubImage(*sourceRectangle).(*ebiten.Image)
+
+	if add != nil {
+		// additive color (for fog) needs a color matrix since color scale can only modulate
+		var cm colorm.ColorM
+		if color != nil {
+			cm.Scale(float64(color.R)/255, float64(color.G)/255, float64(color.B)/255, float64(color.A)/255)
+		}
+		cm.Translate(float64(add.R)/255, float64(add.G)/255, float64(add.B)/255, 0)
+
+		op := &colorm.DrawImageOptions{}
+		op.Filter = ebiten.FilterNearest
+		op.Blend = blend
+
+		op.GeoM.Scale(scaleX, scaleY)
+		op.GeoM.Translate(float64(destinationRectangle.Min.X), float64(destinationRectangle.Min.Y))
+
+		colorm.DrawImage(screen, destTexture, cm, op)
+		return
+	}
+
 	op := &ebiten.DrawImageOptions{}
 	op.Filter = ebiten.FilterNearest
+	op.Blend = blend
 
 	op.GeoM.Scale(scaleX, scaleY)
 	op.GeoM.Translate(float64(destinationRectangle.Min.X), float64(destinationRectangle.Min.Y))
 
-	destTexture := texture.SubImage(*sourceRectangle).(*ebiten.Image)
-
 	if color != nil {
 		// color channel modulation/tinting
-		op.ColorScale.Scale(float32(color.R)/255, float32(color.G)/255, float32(color.B)/255, float32(color.A)/255)
+		op.ColorScale.Scale(float32(color.R)/255, float32(color.G)/255, float32(color.B)/255, 1)
+
+		// translucency (color scale is applied to premultiplied alpha colors)
+		op.ColorScale.ScaleAlpha(float32(color.A) / 255)
 	}
 
 	screen.DrawImage(destTexture, op)
@@ -0,0 +1,102 @@
+package raycaster
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type SkyMode int
+
+const (
+	// SkyStatic stretches the sky texture over the top half of the screen regardless of camera heading
+	SkyStatic SkyMode = iota
+	// SkyPanoramic wraps the sky texture 360 degrees horizontally around the camera,
+	// scrolling with the camera heading and shifting vertically with the camera pitch
+	SkyPanoramic
+)
+
+// SkyLayer represents an image drawn over a panoramic sky that scrolls on its own, such as parallax clouds
+type SkyLayer struct {
+	// Image wraps horizontally the same as the panoramic sky, transparent pixels show what is behind it
+	Image *ebiten.Image
+
+	// Parallax scales how fast the layer scrolls with the camera heading (1.0 scrolls along with the sky)
+	Parallax float64
+
+	// Offset rotates the layer horizontally (radians), update each tick to have the layer drift
+	Offset float64
+}
+
+// NewSkyLayer creates a sky layer that scrolls with the camera heading at the given parallax rate
+func NewSkyLayer(image *ebiten.Image, parallax float64) *SkyLayer {
+	return &SkyLayer{Image: image, Parallax: parallax}
+}
+
+// SetSkyMode sets how the skybox texture is rendered (SkyStatic or SkyPanoramic)
+func (c *Camera) SetSkyMode(mode SkyMode) {
+	c.skyMode = mode
+}
+
+// SkyMode returns how the skybox texture is rendered
+func (c *Camera) SkyMode() SkyMode {
+	return c.skyMode
+}
+
+// SetSkyLayers sets the layers drawn in order over the panoramic sky (nil to remove them)
+func (c *Camera) SetSkyLayers(layers []*SkyLayer) {
+	c.skyLayers = layers
+}
+
+// drawSky renders the skybox texture and layers above the horizon
+func (c *Camera) drawSky(screen *ebiten.Image, lighting *color.RGBA) {
+	horizon := int(float64(c.h)*0.5) + c.pitch
+
+	if c.skyMode != SkyPanoramic {
+		texRect := image.Rect(0, 0, c.texSize, c.texSize)
+		skyRect := image.Rect(0, 0, c.w, horizon)
+		drawTexture(screen, c.sky, &skyRect, &texRect, lighting, nil, ebiten.BlendSourceOver)
+		return
+	}
+
+	c.drawPanorama(screen, c.sky, 1, 0, horizon, lighting)
+	for _, layer := range c.skyLayers {
+		if layer != nil {
+			c.drawPanorama(screen, layer.Image, layer.Parallax, layer.Offset, horizon, lighting)
+		}
+	}
+}
+
+// drawPanorama renders an image wrapping 360 degrees around the camera with its bottom at the horizon
+func (c *Camera) drawPanorama(screen, panorama *ebiten.Image, parallax, offset float64, horizon int, lighting *color.RGBA) {
+	if panorama == nil {
+		return
+	}
+
+	// the image is as tall as the sky seen with the camera pitched all the way up,
+	// and as wide as the FOV is of a full turn
+	imgW, imgH := panorama.Bounds().Dx(), panorama.Bounds().Dy()
+	skyHeight := float64(c.h) * (0.5 + c.fovDepth)
+	panoramaWidth := float64(c.w) * 2 * math.Pi / c.fovAngle
+
+	// angles increase towards the left edge of the screen, which is at the heading plus half of the FOV
+	leftAngle := c.headingAngle*parallax + offset + c.fovAngle/2
+	leftPos := math.Mod(-leftAngle/(2*math.Pi), 1)
+	if leftPos < 0 {
+		leftPos += 1
+	}
+
+	// draw the image again after itself until it wraps past the right edge of the screen
+	for drawX := -leftPos * panoramaWidth; drawX < float64(c.w); drawX += panoramaWidth {
+		op := &ebiten.DrawImageOptions{}
+		op.Filter = ebiten.FilterNearest
+		op.GeoM.Scale(panoramaWidth/float64(imgW), skyHeight/float64(imgH))
+		op.GeoM.Translate(drawX, float64(horizon)-skyHeight)
+		if lighting != nil {
+			op.ColorScale.Scale(float32(lighting.R)/255, float32(lighting.G)/255, float32(lighting.B)/255, 1)
+		}
+		screen.DrawImage(panorama, op)
+	}
+}
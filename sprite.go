@@ -2,6 +2,8 @@ package raycaster
 
 import (
 	"image"
+	"image/color"
+	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/harbdog/raycaster-go/geom"
@@ -36,6 +38,57 @@ type Sprite interface {
 	IsFocusable() bool
 }
 
+// TranslucentSprite is an optional extension of Sprite for rendering with opacity and blending
+type TranslucentSprite interface {
+	// Opacity returns the opacity of the sprite from 0.0 (invisible) to 1.0 (opaque)
+	Opacity() float64
+
+	// BlendMode returns how the sprite blends with what is rendered behind it
+	// (for normal alpha blending, default to ebiten.BlendSourceOver)
+	BlendMode() ebiten.Blend
+}
+
+// TintedSprite is an optional extension of Sprite for coloring it, such as team colors, frozen tints, or hit flashes
+type TintedSprite interface {
+	// Tint returns the color multiplied with the lighted sprite texture
+	// (for no tint, default to opaque white)
+	Tint() color.NRGBA
+
+	// Flash returns the color added to the sprite, its alpha is how much it replaces the lighted sprite texture
+	// (for no flash, default to transparent)
+	Flash() color.NRGBA
+}
+
+// OrientedSprite is an optional extension of Sprite for flat sprites with a fixed orientation in the map
+// instead of facing the camera, such as a sign hanging in a corridor or a grate standing in a room
+type OrientedSprite interface {
+	// Orientation returns the angle (radians) that the flat sprite lies along, 0.0 along the positive X-axis
+	Orientation() float64
+}
+
+// DirectionalSprite is an optional extension of Sprite for showing different textures depending on the angle
+// between the facing of the sprite and the camera, such as an enemy seen from the side or from behind
+type DirectionalSprite interface {
+	// Facing returns the angle (radians) the sprite is facing in the map, 0.0 facing the positive X-axis
+	Facing() float64
+
+	// TextureForAngle returns the image and the rectangle of the texture coordinates to draw for the view angle
+	// (radians, 0.0 to 2*Pi) of the camera relative to the facing of the sprite, used instead of Texture and TextureRect.
+	// A view angle of 0.0 sees the front of the sprite, Pi sees the back of it.
+	TextureForAngle(angle float64) (*ebiten.Image, image.Rectangle)
+}
+
+// SpriteDirection returns the index of the direction (0 to numDirections-1) for a view angle of a DirectionalSprite,
+// where 0 is the front and the indices increase with the view angle (for example 8 directions)
+func SpriteDirection(angle float64, numDirections int) int {
+	if numDirections <= 0 {
+		return 0
+	}
+	sector := 2 * math.Pi / float64(numDirections)
+	direction := int(math.Floor((angle + sector/2) / sector))
+	return ((direction % numDirections) + numDirections) % numDirections
+}
+
 type SpriteAnchor int
 
 const (
@@ -61,3 +114,19 @@ func getAnchorVerticalOffset(anchor SpriteAnchor, spriteScale float64, cameraHei
 
 	return 0
 }
+
+// getSpriteTexture returns the image and texture rectangle of the sprite, as viewed from the camera position
+func getSpriteTexture(sprite Sprite, cameraX, cameraY float64) (*ebiten.Image, image.Rectangle) {
+	directional, ok := sprite.(DirectionalSprite)
+	if !ok {
+		return sprite.Texture(), sprite.TextureRect()
+	}
+
+	// angle to the camera from the sprite, relative to the facing of the sprite
+	pos := sprite.Pos()
+	viewAngle := math.Mod(math.Atan2(cameraY-pos.Y, cameraX-pos.X)-directional.Facing(), 2*math.Pi)
+	if viewAngle < 0 {
+		viewAngle += 2 * math.Pi
+	}
+	return directional.TextureForAngle(viewAngle)
+}
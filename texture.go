@@ -13,3 +13,52 @@ type TextureHandler interface {
 	// FloorTextureAt returns image used for textured floor at the given x, y map coordinates
 	FloorTextureAt(x, y int) *image.RGBA
 }
+
+// CeilingTextureHandler is an optional extension of TextureHandler for rendering textured ceilings
+type CeilingTextureHandler interface {
+	// CeilingTextureAt returns image used for textured ceiling at the given x, y map coordinates
+	// (nil shows the skybox through the ceiling)
+	CeilingTextureAt(x, y int) *image.RGBA
+}
+
+// LevelFloorTextureHandler is an optional extension of TextureHandler for rendering floors of each level,
+// including the top faces of walls on the level below
+type LevelFloorTextureHandler interface {
+	// FloorTextureAtLevel returns image used for textured floor at the given x, y map coordinates and level number,
+	// used instead of FloorTextureAt (nil shows what is below the floor)
+	FloorTextureAtLevel(x, y, levelNum int) *image.RGBA
+}
+
+// FaceTextureHandler is an optional extension of TextureHandler for rendering a different texture on each face of a wall
+type FaceTextureHandler interface {
+	// TextureAtFace returns image used for rendered wall at the given x, y map coordinates and level number
+	// as viewed from the given face, used instead of TextureAt
+	TextureAtFace(x, y, levelNum int, face WallFace) *ebiten.Image
+}
+
+type WallFace int
+
+const (
+	// WallFaceWest is the face of a wall block facing the negative X-axis
+	WallFaceWest WallFace = iota
+	// WallFaceEast is the face of a wall block facing the positive X-axis
+	WallFaceEast
+	// WallFaceNorth is the face of a wall block facing the negative Y-axis
+	WallFaceNorth
+	// WallFaceSouth is the face of a wall block facing the positive Y-axis
+	WallFaceSouth
+)
+
+// hitFace returns the face of the map cell that a ray hit from the side it crossed into the cell
+func hitFace(side int, rayDirX, rayDirY float64) WallFace {
+	if side == 0 {
+		if rayDirX > 0 {
+			return WallFaceWest
+		}
+		return WallFaceEast
+	}
+	if rayDirY > 0 {
+		return WallFaceNorth
+	}
+	return WallFaceSouth
+}